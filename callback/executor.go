@@ -2,15 +2,22 @@ package callback
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/marcoshack/schedula/entity"
 	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
 )
 
+// ExecutionIDHeader is the callback request header with the ID of the job execution. It's the
+// same on retries of the execution, so receivers can deduplicate callbacks.
+const ExecutionIDHeader = "X-Schedula-Execution-ID"
+
 // Executor is responsible for executing job's callback
 type Executor interface {
 	Execute(entity.Job) error
@@ -18,28 +25,46 @@ type Executor interface {
 
 // NewExecutor returns an instance of Executor
 func NewExecutor(httpTimeout time.Duration) (Executor, error) {
-	return &SynchronousExecutor{
-		httpClient: &http.Client{Timeout: httpTimeout},
-	}, nil
+	return NewSynchronousExecutor(httpTimeout), nil
+}
+
+// NewSynchronousExecutor returns a SynchronousExecutor timing out the callback requests after the
+// given duration, zero for no timeout
+func NewSynchronousExecutor(httpTimeout time.Duration) *SynchronousExecutor {
+	s := &SynchronousExecutor{httpClient: &http.Client{}}
+	s.SetTimeout(httpTimeout)
+	return s
 }
 
 // SynchronousExecutor ...
 type SynchronousExecutor struct {
 	repository repository.Jobs
 	httpClient *http.Client
+	timeout    int64
+}
+
+// SetTimeout changes the timeout of the callback requests sent from now on
+func (s *SynchronousExecutor) SetTimeout(httpTimeout time.Duration) {
+	atomic.StoreInt64(&s.timeout, int64(httpTimeout))
 }
 
 // Execute ...
 func (s *SynchronousExecutor) Execute(job entity.Job) error {
-	req, err := s.createCallbackRequest(job)
+	req, err := NewRequest(job)
 	if err != nil {
 		return err
 	}
+	if timeout := time.Duration(atomic.LoadInt64(&s.timeout)); timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
 
 	res, err := s.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
 		return fmt.Errorf("invalid callback response, expect 200 OK or 202 Accepted but got %s", res.Status)
@@ -48,7 +73,8 @@ func (s *SynchronousExecutor) Execute(job entity.Job) error {
 	return nil
 }
 
-func (s *SynchronousExecutor) createCallbackRequest(job entity.Job) (*http.Request, error) {
+// NewRequest creates the callback request of the job
+func NewRequest(job entity.Job) (*http.Request, error) {
 	var body = new(bytes.Buffer)
 	encErr := json.NewEncoder(body).Encode(job)
 	if encErr != nil {
@@ -61,5 +87,11 @@ func (s *SynchronousExecutor) createCallbackRequest(job entity.Job) (*http.Reque
 	}
 	req.Header.Set("User-Agent", "schedula")
 	req.Header.Set("Content-Type", "application/json")
+	if job.Claim != nil {
+		req.Header.Set(ExecutionIDHeader, job.Claim.ExecutionID)
+	}
+	if job.TraceParent != "" {
+		req.Header.Set(tracing.TraceparentHeader, job.TraceParent)
+	}
 	return req, nil
 }
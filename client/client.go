@@ -0,0 +1,325 @@
+// Package client calls the Schedula API with the entity types.
+//
+// The API has no endpoint to update a job yet, so neither has the client: a job is changed by
+// canceling it and creating it again, or by creating it with the same UniqueKey and the
+// entity.JobConflictReplace policy, which cancels the pending one. Either way it gets a new ID.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+const (
+	// DefaultRetryBackoff is the delay before the first retry of a request
+	DefaultRetryBackoff = 200 * time.Millisecond
+
+	// MaxRetryDelay caps the delay between retries, including the ones asked by the server
+	MaxRetryDelay = 30 * time.Second
+
+	// IdempotencyKeyHeader is the request header making job creation safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// Config holds Client configuration parameters
+type Config struct {
+	// BaseURL is the URL of the Schedula server, e.g. "http://localhost:8080/"
+	BaseURL string
+	// APIKey authenticates the requests as a bearer token. Blank sends none.
+	APIKey string
+	// HTTPClient sends the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed request is retried. Requests are retried on
+	// 429 Too Many Requests responses, and on network errors and 5xx responses as long as they are
+	// safe to repeat: reads, cancellations and job creations with an idempotency key.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each of the next ones. The
+	// 'Retry-After' of the response takes precedence. Defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+}
+
+// Client calls the Schedula API
+type Client struct {
+	config     Config
+	baseURL    *url.URL
+	httpClient *http.Client
+}
+
+// New returns a Client of the server at the configured BaseURL
+func New(c Config) (*Client, error) {
+	baseURL, err := url.Parse(c.BaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL: '%s'", c.BaseURL)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = DefaultRetryBackoff
+	}
+	return &Client{config: c, baseURL: baseURL, httpClient: httpClient}, nil
+}
+
+// Create schedules the job and returns it as stored by the server. The request carries a random
+// idempotency key, so it's retried without creating the job twice.
+func (c *Client) Create(ctx context.Context, job entity.Job) (entity.Job, error) {
+	return c.CreateWithKey(ctx, NewIdempotencyKey(), job)
+}
+
+// CreateWithKey schedules the job with the given idempotency key. Creating a job again with the
+// same key returns the job created the first time, as long as the server keeps the key. A blank
+// key disables idempotency, and the retries on network errors and 5xx responses.
+//
+// A job with a unique key conflicting with a pending job fails with a 409 Conflict *Error
+// locating the pending job, unless its conflict policy resolves it.
+func (c *Client) CreateWithKey(ctx context.Context, key string, job entity.Job) (entity.Job, error) {
+	header := http.Header{}
+	if key != "" {
+		header.Set(IdempotencyKeyHeader, key)
+	}
+	res, err := c.do(ctx, request{method: "POST", path: "jobs/", body: job, header: header, retryable: key != ""})
+	if err != nil {
+		return entity.Job{}, err
+	}
+	res.Body.Close()
+	location := res.Header.Get("Location")
+	if location == "" {
+		return entity.Job{}, fmt.Errorf("schedula: job created without Location header")
+	}
+	return c.Get(ctx, path.Base(location))
+}
+
+// Get returns the job with the given ID
+func (c *Client) Get(ctx context.Context, id string) (entity.Job, error) {
+	var job entity.Job
+	return job, c.call(ctx, request{method: "GET", path: jobPath(id), retryable: true}, &job)
+}
+
+// List returns a page of the jobs matching the query. The NextCursor of the page is the Cursor of
+// the query for the next one, blank after the last page.
+func (c *Client) List(ctx context.Context, q JobQuery) (JobPage, error) {
+	res, err := c.do(ctx, request{method: "GET", path: "jobs/", query: jobQueryValues(q), retryable: true})
+	if err != nil {
+		return JobPage{}, err
+	}
+	page := JobPage{NextCursor: res.Header.Get("Next-Cursor")}
+	return page, decode(res, &page.Jobs)
+}
+
+// ListAll calls fn with every job matching the query, following the pages until the last one or
+// until fn returns an error
+func (c *Client) ListAll(ctx context.Context, q JobQuery, fn func(entity.Job) error) error {
+	for {
+		page, err := c.List(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, job := range page.Jobs {
+			if err := fn(job); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor, q.Skip = page.NextCursor, 0
+	}
+}
+
+// Cancel cancels the job with the given ID
+func (c *Client) Cancel(ctx context.Context, id string) error {
+	return c.call(ctx, request{method: "DELETE", path: jobPath(id), retryable: true}, nil)
+}
+
+// Pause holds the job until it's resumed, returning the paused job
+func (c *Client) Pause(ctx context.Context, id string) (entity.Job, error) {
+	var job entity.Job
+	return job, c.call(ctx, request{method: "POST", path: jobPath(id) + "/pause"}, &job)
+}
+
+// Resume makes the paused job pending again, with the policy for the runs missed while paused:
+// entity.JobMissedRunOnce, JobMissedRunAll or JobMissedSkip. Blank uses the server default.
+func (c *Client) Resume(ctx context.Context, id string, missed string) (entity.Job, error) {
+	query := url.Values{}
+	if missed != "" {
+		query.Set("missed", missed)
+	}
+	var job entity.Job
+	return job, c.call(ctx, request{method: "POST", path: jobPath(id) + "/resume", query: query}, &job)
+}
+
+// Run executes the job callback right away, leaving its schedule untouched, and returns the
+// execution
+func (c *Client) Run(ctx context.Context, id string) (entity.JobExecution, error) {
+	var execution entity.JobExecution
+	return execution, c.call(ctx, request{method: "POST", path: jobPath(id) + "/run"}, &execution)
+}
+
+// Executions returns a page of the execution history of the job, newest first
+func (c *Client) Executions(ctx context.Context, q ExecutionQuery) (ExecutionPage, error) {
+	query := url.Values{}
+	setParam(query, "status", q.Status)
+	setParam(query, "cursor", q.Cursor)
+	setIntParam(query, "limit", int64(q.Limit))
+	res, err := c.do(ctx, request{method: "GET", path: jobPath(q.JobID) + "/executions", query: query, retryable: true})
+	if err != nil {
+		return ExecutionPage{}, err
+	}
+	page := ExecutionPage{NextCursor: res.Header.Get("Next-Cursor")}
+	return page, decode(res, &page.Executions)
+}
+
+// NewIdempotencyKey returns a random idempotency key
+func NewIdempotencyKey() string {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
+
+// request is an API call. Retryable requests are safe to repeat after network errors and 5xx
+// responses, while every request is retried on 429 Too Many Requests, as those aren't processed.
+type request struct {
+	method    string
+	path      string
+	query     url.Values
+	header    http.Header
+	body      interface{}
+	retryable bool
+}
+
+// call sends the request and decodes the response body into v, unless nil
+func (c *Client) call(ctx context.Context, r request, v interface{}) error {
+	res, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		res.Body.Close()
+		return nil
+	}
+	return decode(res, v)
+}
+
+// do sends the request, retrying it according to the configuration, and returns the 2xx response.
+// Other responses are returned as an *Error.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return nil, fmt.Errorf("unable to encode request body: %v", err)
+		}
+	}
+	target := c.baseURL.ResolveReference(&url.URL{Path: r.path, RawQuery: r.query.Encode()})
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, r.method, target.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range r.header {
+			req.Header[name] = values
+		}
+		req.Header.Set("User-Agent", "schedula-client")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.config.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+		}
+
+		var delay time.Duration
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !r.retryable || attempt >= c.config.MaxRetries {
+				return nil, err
+			}
+		} else if res.StatusCode >= 200 && res.StatusCode < 300 {
+			return res, nil
+		} else {
+			apiErr := responseError(res)
+			res.Body.Close()
+			retry := apiErr.StatusCode == http.StatusTooManyRequests || (r.retryable && apiErr.StatusCode >= 500)
+			if !retry || attempt >= c.config.MaxRetries {
+				return nil, apiErr
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		if delay <= 0 {
+			delay = c.config.RetryBackoff << uint(attempt)
+		}
+		if delay > MaxRetryDelay || delay <= 0 {
+			delay = MaxRetryDelay
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func decode(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("unable to decode response body: %v", err)
+	}
+	return nil
+}
+
+func jobPath(id string) string {
+	return "jobs/" + url.PathEscape(id)
+}
+
+// jobQueryValues returns the List parameters of the query
+func jobQueryValues(q JobQuery) url.Values {
+	values := url.Values{}
+	setParam(values, "status", q.Filter.Status)
+	setParam(values, "clientKey", q.Filter.ClientKey)
+	setParam(values, "callbackHost", q.Filter.CallbackHost)
+	setIntParam(values, "scheduledAfter", q.Filter.ScheduledAfter)
+	setIntParam(values, "scheduledBefore", q.Filter.ScheduledBefore)
+	setIntParam(values, "createdAfter", q.Filter.CreatedAfter)
+	setIntParam(values, "createdBefore", q.Filter.CreatedBefore)
+	setParam(values, "sort", q.SortBy)
+	setParam(values, "order", q.SortOrder)
+	setParam(values, "cursor", q.Cursor)
+	setIntParam(values, "skip", int64(q.Skip))
+	setIntParam(values, "limit", int64(q.Limit))
+	return values
+}
+
+func setParam(values url.Values, name string, value string) {
+	if value != "" {
+		values.Set(name, value)
+	}
+}
+
+func setIntParam(values url.Values, name string, value int64) {
+	if value != 0 {
+		values.Set(name, strconv.FormatInt(value, 10))
+	}
+}
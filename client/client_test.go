@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+	"github.com/marcoshack/schedula/handler"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// testServer hosts the API handlers over an in-memory repository, with authentication enabled
+type testServer struct {
+	*httptest.Server
+	jobs     repository.Jobs
+	adminKey string
+	// failures is the number of next requests handled but answered with 502 Bad Gateway
+	failures int32
+}
+
+type runnerStub struct {
+	jobs repository.Jobs
+}
+
+func (r *runnerStub) Run(job entity.Job) (entity.JobExecution, error) {
+	execution := entity.JobExecution{Timestamp: time.Now().Unix(), Status: entity.JobStatusSuccess, Trigger: entity.JobTriggerManual}
+	_, err := r.jobs.AppendExecution(job.ID, execution)
+	return execution, err
+}
+
+func newTestServer(t *testing.T, limits entity.ClientLimits) *testServer {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	keys, err := repository.NewAPIKeys("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize API keys: %v", err)
+	}
+	admin, err := keys.Add(entity.APIKey{Role: entity.RoleAdmin})
+	if err != nil {
+		t.Fatalf("unable to add admin key: %v", err)
+	}
+
+	bus := events.NewBus(0)
+	limited := quota.NewJobs(events.NewJobs(repo, bus), limits)
+	jobs := handler.NewJobsHandler("/jobs/", limited, handler.JobsConfig{IdempotencyWindow: time.Hour, IdempotencyPerClient: true})
+	run := handler.NewRunHandler(limited, &runnerStub{jobs: repo})
+	router := mux.NewRouter()
+	router.HandleFunc("/jobs/", jobs.List).Methods("GET")
+	router.HandleFunc("/jobs/", jobs.Create).Methods("POST")
+	router.HandleFunc("/jobs/{id}", jobs.Find).Methods("GET")
+	router.HandleFunc("/jobs/{id}", jobs.Delete).Methods("DELETE")
+	router.HandleFunc("/jobs/{id}/pause", jobs.Pause).Methods("POST")
+	router.HandleFunc("/jobs/{id}/resume", jobs.Resume).Methods("POST")
+	router.HandleFunc("/jobs/{id}/run", run.Run).Methods("POST")
+	router.HandleFunc("/jobs/{id}/executions", jobs.Executions).Methods("GET")
+	router.HandleFunc("/events", handler.NewEventsHandler(bus).Stream).Methods("GET")
+	api := handler.NewAuthenticator(keys, true).Wrap(router)
+
+	s := &testServer{jobs: repo, adminKey: admin.Secret}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&s.failures, -1) >= 0 {
+			api.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		bus.Close()
+		s.Close()
+	})
+	return s
+}
+
+func newTestClient(t *testing.T, s *testServer, maxRetries int) *Client {
+	c, err := New(Config{BaseURL: s.URL, APIKey: s.adminKey, MaxRetries: maxRetries, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return c
+}
+
+func testJob(clientKey string) entity.Job {
+	return entity.Job{
+		ClientKey:   clientKey,
+		CallbackURL: "http://example.com/callback",
+		Schedule:    entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix())},
+	}
+}
+
+func TestClient_CreateGetCancel(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 0)
+	ctx := context.Background()
+
+	job, err := c.Create(ctx, testJob("acme"))
+	if err != nil {
+		t.Fatalf("unable to create job: %v", err)
+	}
+	if job.ID == "" || job.Status != entity.JobStatusPending || job.ClientKey != "acme" {
+		t.Fatalf("expected pending job of acme but got %+v", job)
+	}
+	if err := c.Cancel(ctx, job.ID); err != nil {
+		t.Fatalf("unable to cancel job: %v", err)
+	}
+	if job, err = c.Get(ctx, job.ID); err != nil || job.Status != entity.JobStatusCanceled {
+		t.Fatalf("expected canceled job but got %+v, error %v", job, err)
+	}
+	if _, err := c.Get(ctx, "unknown"); !IsNotFound(err) {
+		t.Fatalf("expected not found error but got %v", err)
+	}
+}
+
+func TestClient_CreateRetriesWithIdempotencyKey(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 2)
+	s.failures = 2
+
+	job, err := c.Create(context.Background(), testJob("acme"))
+	if err != nil {
+		t.Fatalf("unable to create job: %v", err)
+	}
+	if n := s.jobs.Count(repository.JobFilter{}); n != 1 {
+		t.Fatalf("expected retries to create a single job but got %d", n)
+	}
+	if stored, _ := s.jobs.Get(job.ID); stored.ID != job.ID {
+		t.Fatalf("expected the job created by the first attempt but got %+v", job)
+	}
+}
+
+func TestClient_CreateWithoutKeyIsNotRetried(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 2)
+	s.failures = 1
+
+	_, err := c.CreateWithKey(context.Background(), "", testJob("acme"))
+	if apiErr, ok := err.(*Error); !ok || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 error but got %v", err)
+	}
+	if n := s.jobs.Count(repository.JobFilter{}); n != 1 {
+		t.Fatalf("expected a single job but got %d", n)
+	}
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{CreateRate: 0.001, CreateBurst: 1})
+	c := newTestClient(t, s, 0)
+	ctx := context.Background()
+
+	if _, err := c.Create(ctx, testJob("acme")); err != nil {
+		t.Fatalf("unable to create job: %v", err)
+	}
+	_, err := c.Create(ctx, testJob("acme"))
+	if !IsRateLimited(err) || err.(*Error).RetryAfter <= 0 || err.(*Error).Message == "" {
+		t.Fatalf("expected rate limited error with Retry-After but got %#v", err)
+	}
+}
+
+func TestClient_ListAll(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 0)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := c.Create(ctx, testJob("acme")); err != nil {
+			t.Fatalf("unable to create job: %v", err)
+		}
+	}
+	c.Create(ctx, testJob("other"))
+
+	query := JobQuery{Filter: JobFilter{ClientKey: "acme"}, Limit: 2}
+	page, err := c.List(ctx, query)
+	if err != nil || len(page.Jobs) != 2 || page.NextCursor == "" {
+		t.Fatalf("expected first page of 2 jobs with cursor but got %+v, error %v", page, err)
+	}
+	listed := 0
+	err = c.ListAll(ctx, query, func(job entity.Job) error {
+		if job.ClientKey != "acme" {
+			t.Fatalf("expected only jobs of acme but got %+v", job)
+		}
+		listed++
+		return nil
+	})
+	if err != nil || listed != 5 {
+		t.Fatalf("expected 5 jobs listed but got %d, error %v", listed, err)
+	}
+}
+
+func TestClient_PauseResumeRun(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 0)
+	ctx := context.Background()
+	job, err := c.Create(ctx, testJob("acme"))
+	if err != nil {
+		t.Fatalf("unable to create job: %v", err)
+	}
+
+	if job, err = c.Pause(ctx, job.ID); err != nil || job.Status != entity.JobStatusPaused {
+		t.Fatalf("expected paused job but got %+v, error %v", job, err)
+	}
+	if _, err = c.Pause(ctx, "unknown"); !IsNotFound(err) {
+		t.Fatalf("expected not found pausing unknown job but got %v", err)
+	}
+	if job, err = c.Resume(ctx, job.ID, entity.JobMissedSkip); err != nil || job.Status != entity.JobStatusPending {
+		t.Fatalf("expected pending job but got %+v, error %v", job, err)
+	}
+	execution, err := c.Run(ctx, job.ID)
+	if err != nil || execution.Trigger != entity.JobTriggerManual {
+		t.Fatalf("expected manual execution but got %+v, error %v", execution, err)
+	}
+	page, err := c.Executions(ctx, ExecutionQuery{JobID: job.ID})
+	if err != nil || len(page.Executions) != 1 {
+		t.Fatalf("expected 1 execution but got %+v, error %v", page, err)
+	}
+}
+
+func TestClient_Events(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c := newTestClient(t, s, 0)
+	ctx := context.Background()
+	created := make([]entity.Job, 3)
+	for i := range created {
+		created[i], _ = c.Create(ctx, testJob("acme"))
+	}
+
+	var received []events.Event
+	stop := fmt.Errorf("stop")
+	lastID, err := c.Events(ctx, events.Filter{Type: events.JobCreated}, 1, func(e events.Event) error {
+		received = append(received, e)
+		if len(received) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || lastID != 3 {
+		t.Fatalf("expected to stop at event 3 but got %d, error %v", lastID, err)
+	}
+	if received[0].JobID != created[1].ID || received[1].JobID != created[2].ID {
+		t.Fatalf("expected the created events after the first one but got %+v", received)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	c, _ := New(Config{BaseURL: s.URL, APIKey: "invalid"})
+
+	if _, err := c.Get(context.Background(), "any"); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized error but got %v", err)
+	}
+}
+
+func TestClient_ContextCanceledWhileRetrying(t *testing.T) {
+	s := newTestServer(t, entity.ClientLimits{})
+	s.failures = 10
+	c, _ := New(Config{BaseURL: s.URL, APIKey: s.adminKey, MaxRetries: 5, RetryBackoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.Get(ctx, "any"); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected retries to stop with the context but took %v", elapsed)
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	if _, err := New(Config{BaseURL: "localhost"}); err == nil {
+		t.Fatalf("expected error for base URL without scheme")
+	}
+}
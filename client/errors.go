@@ -0,0 +1,71 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is how long the server asked to wait before retrying, zero when it didn't
+	RetryAfter time.Duration
+	// Location is the job the error refers to, e.g. the pending job with the same unique key
+	Location string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("schedula: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("schedula: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound returns whether the error is a 404 Not Found response, e.g. for unknown jobs or
+// jobs of other clients
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict returns whether the error is a 409 Conflict response, e.g. for a job whose unique
+// key or idempotency key is already used
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsRateLimited returns whether the error is a 429 Too Many Requests response, for clients over
+// their limits
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsUnauthorized returns whether the error is a 401 Unauthorized or 403 Forbidden response
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+func hasStatus(err error, status int) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == status
+}
+
+// responseError returns the *Error of an error response
+func responseError(res *http.Response) *Error {
+	err := &Error{StatusCode: res.StatusCode, Location: res.Header.Get("Location")}
+	if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	var message struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &message) == nil {
+		err.Message = message.Error
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/marcoshack/schedula/events"
+)
+
+// Events streams the job events matching the filter, calling fn with each of them until the
+// server ends the stream, the context is done or fn returns an error, which is returned. When
+// lastID is given the events kept after it are received first. It returns the ID of the last
+// event received, to resume the stream from.
+func (c *Client) Events(ctx context.Context, filter events.Filter, lastID uint64, fn func(events.Event) error) (uint64, error) {
+	query := url.Values{}
+	setParam(query, "jobId", filter.JobID)
+	setParam(query, "clientKey", filter.ClientKey)
+	setParam(query, "status", filter.Status)
+	setParam(query, "type", filter.Type)
+	header := http.Header{}
+	if lastID > 0 {
+		header.Set("Last-Event-ID", strconv.FormatUint(lastID, 10))
+	}
+	res, err := c.do(ctx, request{method: "GET", path: "events", query: query, header: header, retryable: true})
+	if err != nil {
+		return lastID, err
+	}
+	defer res.Body.Close()
+
+	var data strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		case line == "" && data.Len() > 0:
+			var e events.Event
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return lastID, fmt.Errorf("unable to decode event: %v", err)
+			}
+			data.Reset()
+			lastID = e.ID
+			if err := fn(e); err != nil {
+				return lastID, err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return lastID, ctx.Err()
+	}
+	return lastID, scanner.Err()
+}
@@ -0,0 +1,63 @@
+package client
+
+import "github.com/marcoshack/schedula/entity"
+
+const (
+	// SortByCreatedAt orders jobs by their creation time
+	SortByCreatedAt = "createdAt"
+
+	// SortByNextRun orders jobs by the next time they are scheduled to run
+	SortByNextRun = "nextRun"
+
+	// SortAsc ...
+	SortAsc = "asc"
+
+	// SortDesc ...
+	SortDesc = "desc"
+)
+
+// JobFilter restricts the jobs listed, sent as the List parameters. Zero values match any job.
+// Time ranges are epoch timestamps, 'After' bounds are inclusive and 'Before' bounds are exclusive.
+// Clients only list their own jobs whatever the ClientKey, which only filters the jobs of admins.
+type JobFilter struct {
+	Status          string
+	ClientKey       string
+	CallbackHost    string
+	ScheduledAfter  int64
+	ScheduledBefore int64
+	CreatedAfter    int64
+	CreatedBefore   int64
+}
+
+// JobQuery describes which jobs to list and in which order, SortByCreatedAt or SortByNextRun and
+// SortAsc or SortDesc. The zero value lists jobs in creation order, in pages of the server default
+// size. Cursor is the NextCursor of a previous page of the same query.
+type JobQuery struct {
+	Filter    JobFilter
+	SortBy    string
+	SortOrder string
+	Cursor    string
+	Skip      int
+	Limit     int
+}
+
+// JobPage is a page of a job listing. NextCursor is blank when there are no more jobs to list.
+type JobPage struct {
+	Jobs       []entity.Job
+	NextCursor string
+}
+
+// ExecutionQuery describes the page of the execution history of a job to list. Status restricts
+// the executions to the ones with the given status.
+type ExecutionQuery struct {
+	JobID  string
+	Status string
+	Cursor string
+	Limit  int
+}
+
+// ExecutionPage is a page of executions, newest first. NextCursor is blank on the last page.
+type ExecutionPage struct {
+	Executions []entity.JobExecution
+	NextCursor string
+}
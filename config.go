@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marcoshack/schedula/logging"
+)
+
+// envPrefix prefixes the environment variables setting the flags, e.g. SCHEDULA_REPO_TYPE sets
+// -repo-type
+const envPrefix = "SCHEDULA_"
+
+// flagAliases are the names of the single letter flags in config files and environment variables
+var flagAliases = map[string]string{"bind": "b", "port": "p", "workers": "w"}
+
+// sourceFlags select the configuration sources, so config files and environment variables can't
+// set them, except SCHEDULA_CONFIG for the config file path
+var sourceFlags = map[string]bool{"config": true, "print-config": true}
+
+// clientEnvVars are the SCHEDULA_* environment variables of the client tools, like the API key of
+// examples/keys and schedulactl, which the server ignores
+var clientEnvVars = map[string]bool{envPrefix + "API_KEY": true}
+
+// secretFlags are masked by -print-config
+var secretFlags = map[string]bool{"admin-key": true, "repo-dsn": true}
+
+// ignoredVariable is an environment variable ignored by the config sources, logged once the
+// logging is set up
+type ignoredVariable struct {
+	name string
+	err  error
+}
+
+// logIgnored warns about the ignored environment variables
+func logIgnored(ignored []ignoredVariable) {
+	for _, v := range ignored {
+		logger.Warn("ignoring environment variable", "variable", v.name, logging.KeyError, v.err)
+	}
+}
+
+// configValue is a flag value read from a config source, to report where invalid values come from
+type configValue struct {
+	value  string
+	source string
+}
+
+// givenFlags returns the names of the flags given in the command line, which must be called before
+// loading the config sources
+func givenFlags(fs *flag.FlagSet) map[string]bool {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	return given
+}
+
+// loadConfigSources sets the flags not given in the command line from the SCHEDULA_* environment
+// variables and then from the JSON config file, so the command line takes precedence over the
+// environment, which takes precedence over the file and then over the defaults. It returns the
+// environment variables ignored, for the caller to log.
+func loadConfigSources(fs *flag.FlagSet, given map[string]bool, environ []string) ([]ignoredVariable, error) {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		path = lookupEnv(environ, envPrefix+"CONFIG")
+	}
+	values := map[string]configValue{}
+	if path != "" {
+		if err := readConfigFile(fs, path, values); err != nil {
+			return nil, err
+		}
+	}
+	env, ignored := readConfigEnv(fs, environ)
+	for name, v := range env {
+		values[name] = v
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if given[name] {
+			continue
+		}
+		v := values[name]
+		if err := fs.Set(name, v.value); err != nil {
+			return ignored, fmt.Errorf("%s: invalid value '%s': %v", v.source, v.value, err)
+		}
+	}
+	return ignored, nil
+}
+
+// readConfigFile reads the flag values from a JSON object keyed by flag name. Values are strings,
+// numbers or booleans, and objects for the key=value lists like repo-options.
+func readConfigFile(fs *flag.FlagSet, path string, values map[string]configValue) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var file map[string]interface{}
+	if err := decoder.Decode(&file); err != nil {
+		return fmt.Errorf("invalid config file '%s': %v", path, err)
+	}
+	for key, raw := range file {
+		source := fmt.Sprintf("config file '%s', key '%s'", path, key)
+		name, err := configFlagName(fs, key)
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		value, err := configFileValue(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		values[name] = configValue{value: value, source: source}
+	}
+	return nil
+}
+
+func configFileValue(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, raw := range v {
+			value, err := configFileValue(raw)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value: %v", raw)
+}
+
+// readConfigEnv reads the flag values from the SCHEDULA_* environment variables, named after the
+// flags in upper case with underscores, e.g. SCHEDULA_HOST_IDLE_TIMEOUT or SCHEDULA_PORT. Unknown
+// variables are returned as ignored, to be logged as warnings, since the shell may export them for
+// other tools.
+func readConfigEnv(fs *flag.FlagSet, environ []string) (map[string]configValue, []ignoredVariable) {
+	values := map[string]configValue{}
+	var ignored []ignoredVariable
+	for _, entry := range environ {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, envPrefix) || key == envPrefix+"CONFIG" || clientEnvVars[key] {
+			continue
+		}
+		name, err := configFlagName(fs, strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, envPrefix), "_", "-")))
+		if err != nil {
+			ignored = append(ignored, ignoredVariable{name: key, err: err})
+			continue
+		}
+		values[name] = configValue{value: value, source: "environment variable " + key}
+	}
+	return values, ignored
+}
+
+// configFlagName returns the name of the flag set by a config source key
+func configFlagName(fs *flag.FlagSet, key string) (string, error) {
+	if len(key) == 1 || sourceFlags[key] {
+		return "", fmt.Errorf("unknown setting '%s'", key)
+	}
+	name := key
+	if alias, ok := flagAliases[key]; ok {
+		name = alias
+	}
+	if fs.Lookup(name) == nil {
+		return "", fmt.Errorf("unknown setting '%s'", key)
+	}
+	return name, nil
+}
+
+func lookupEnv(environ []string, key string) string {
+	for _, entry := range environ {
+		if k, v, _ := strings.Cut(entry, "="); k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// resetConfigSources sets the flags not given in the command line back to their defaults, so the
+// config sources can be loaded again
+func resetConfigSources(fs *flag.FlagSet, given map[string]bool) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if !given[f.Name] {
+			f.Value.Set(f.DefValue)
+		}
+	})
+}
+
+// flagValues returns the current value of every flag
+func flagValues(fs *flag.FlagSet) map[string]string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) { values[f.Name] = f.Value.String() })
+	return values
+}
+
+// configKey returns the name of the flag in the config sources
+func configKey(name string) string {
+	for alias, flagName := range flagAliases {
+		if flagName == name {
+			return alias
+		}
+	}
+	return name
+}
+
+// printConfig writes the effective flag values as a JSON config file, with the secrets masked
+func printConfig(w io.Writer, fs *flag.FlagSet) error {
+	values := map[string]interface{}{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if sourceFlags[f.Name] {
+			return
+		}
+		var value interface{} = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		}
+		if secretFlags[f.Name] && f.Value.String() != "" {
+			value = "********"
+		}
+		values[configKey(f.Name)] = value
+	})
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// parseOptions parses a comma separated list of key=value pairs
+func parseOptions(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	options := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid option: '%s'", pair)
+		}
+		options[key] = value
+	}
+	return options, nil
+}
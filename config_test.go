@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testFlagSet(args ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("config", "", "")
+	fs.Bool("print-config", false, "")
+	fs.Int("p", 8080, "")
+	fs.String("repo-type", "in-memory", "")
+	fs.String("timeout", "5s", "")
+	fs.String("repo-options", "", "")
+	fs.String("admin-key", "", "")
+	fs.Parse(args)
+	return fs
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "schedula.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+	return path
+}
+
+func Test_LoadConfigSources_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `{"port": 9000, "repo-type": "mysql", "timeout": "10s", "repo-options": {"pool-size": 10, "max-retries": 3}}`)
+	fs := testFlagSet("-config", path, "-timeout", "1s")
+
+	if _, err := loadConfigSources(fs, givenFlags(fs), []string{"SCHEDULA_PORT=9001", "PORT=9002"}); err != nil {
+		t.Fatalf("unable to load config: %v", err)
+	}
+	expected := map[string]string{"p": "9001", "repo-type": "mysql", "timeout": "1s", "repo-options": "max-retries=3,pool-size=10"}
+	for name, value := range expected {
+		if actual := fs.Lookup(name).Value.String(); actual != value {
+			t.Fatalf("expected %s to be '%s' but got '%s'", name, value, actual)
+		}
+	}
+}
+
+func Test_LoadConfigSources_ConfigFromEnvironment(t *testing.T) {
+	fs := testFlagSet()
+	path := writeConfigFile(t, `{"repo-type": "redis"}`)
+
+	if _, err := loadConfigSources(fs, givenFlags(fs), []string{"SCHEDULA_CONFIG=" + path}); err != nil {
+		t.Fatalf("unable to load config: %v", err)
+	}
+	if actual := fs.Lookup("repo-type").Value.String(); actual != "redis" {
+		t.Fatalf("expected repo-type from config file but got '%s'", actual)
+	}
+}
+
+func Test_LoadConfigSources_Errors(t *testing.T) {
+	cases := map[string]struct {
+		file    string
+		environ []string
+	}{
+		"unknown file key":    {file: `{"prot": 9000}`},
+		"invalid file value":  {file: `{"port": "nine"}`},
+		"unsupported value":   {file: `{"timeout": ["5s"]}`},
+		"source flag in file": {file: `{"print-config": true}`},
+		"malformed file":      {file: `{"port": `},
+		"invalid env value":   {environ: []string{"SCHEDULA_PORT=nine"}},
+	}
+	for name, c := range cases {
+		fs := testFlagSet()
+		if c.file != "" {
+			fs.Set("config", writeConfigFile(t, c.file))
+		}
+		if _, err := loadConfigSources(fs, givenFlags(fs), c.environ); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func Test_LoadConfigSources_IgnoresUnknownEnvironment(t *testing.T) {
+	fs := testFlagSet()
+	environ := []string{"SCHEDULA_API_KEY=secret", "SCHEDULA_PROT=9000", "SCHEDULA_P=9000", "SCHEDULA_PORT=9001"}
+
+	ignored, err := loadConfigSources(fs, givenFlags(fs), environ)
+	if err != nil {
+		t.Fatalf("expected unknown environment variables to be ignored but got %v", err)
+	}
+	if len(ignored) != 2 {
+		t.Fatalf("expected SCHEDULA_PROT and SCHEDULA_P to be reported as ignored but got %v", ignored)
+	}
+	if actual := fs.Lookup("p").Value.String(); actual != "9001" {
+		t.Fatalf("expected port from environment but got '%s'", actual)
+	}
+}
+
+func Test_PrintConfig(t *testing.T) {
+	fs := testFlagSet("-admin-key", "secret", "-p", "9000")
+	var out bytes.Buffer
+
+	if err := printConfig(&out, fs); err != nil {
+		t.Fatalf("unable to print config: %v", err)
+	}
+	var printed map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("expected JSON config but got %s", out.String())
+	}
+	if printed["port"] != float64(9000) || printed["repo-type"] != "in-memory" || printed["admin-key"] != "********" {
+		t.Fatalf("unexpected printed config: %v", printed)
+	}
+	if _, ok := printed["config"]; ok {
+		t.Fatalf("expected config source flags to be omitted: %v", printed)
+	}
+}
+
+func Test_ParseOptions(t *testing.T) {
+	options, err := parseOptions("pool-size=10, max-retries=3")
+	if err != nil || options["pool-size"] != "10" || options["max-retries"] != "3" {
+		t.Fatalf("unexpected options %v, error %v", options, err)
+	}
+	if _, err := parseOptions("pool-size"); err == nil {
+		t.Fatalf("expected error for option without value")
+	}
+}
@@ -0,0 +1,30 @@
+package entity
+
+const (
+	// RoleClient restricts an API key to the jobs of its ClientKey
+	RoleClient = "client"
+
+	// RoleAdmin grants access to the jobs of all clients and to the management API
+	RoleAdmin = "admin"
+)
+
+// APIKey authenticates API callers as a client. Secret is only known when the key is issued,
+// repositories keep just its hash.
+type APIKey struct {
+	ID        string `json:"id"`
+	Secret    string `json:"secret,omitempty"`
+	ClientKey string `json:"clientKey"`
+	Role      string `json:"role"`
+	CreatedAt int64  `json:"createdAt"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// IsAdmin returns whether the key has the admin role
+func (k *APIKey) IsAdmin() bool {
+	return k.Role == RoleAdmin
+}
+
+// IsValidRole returns whether the key role is one of the known roles
+func (k *APIKey) IsValidRole() bool {
+	return k.Role == RoleClient || k.Role == RoleAdmin
+}
@@ -20,6 +20,33 @@ const (
 
 	// JobStatusCanceled ...
 	JobStatusCanceled = "canceled"
+
+	// JobStatusPaused holds a pending job until it's resumed
+	JobStatusPaused = "paused"
+
+	// JobStatusSkipped is the status of a job whose run was missed while paused and skipped
+	JobStatusSkipped = "skipped"
+
+	// JobConflictReject rejects a job with the unique key of an existing pending job
+	JobConflictReject = "reject"
+
+	// JobConflictReplace cancels the existing pending job and adds the new one
+	JobConflictReplace = "replace"
+
+	// JobConflictKeepEarliest keeps whichever job is scheduled to run first
+	JobConflictKeepEarliest = "keep-earliest"
+
+	// JobMissedRunOnce runs a resumed job once for all the occurrences missed while paused
+	JobMissedRunOnce = "run-once"
+
+	// JobMissedRunAll runs a resumed job for each occurrence missed while paused
+	JobMissedRunAll = "run-all"
+
+	// JobMissedSkip skips the occurrences of a resumed job missed while paused
+	JobMissedSkip = "skip"
+
+	// JobTriggerManual marks the executions triggered on demand instead of by the job schedule
+	JobTriggerManual = "manual"
 )
 
 // Job ...
@@ -30,12 +57,49 @@ type Job struct {
 	Data        map[string]string `json:"data"`
 	Schedule    JobSchedule       `json:"schedule"`
 	Status      string            `json:"status"`
+	UniqueKey   string            `json:"uniqueKey,omitempty"`
+	OnConflict  string            `json:"onConflict,omitempty"`
+	CreatedAt   int64             `json:"createdAt"`
+	NextRun     int64             `json:"nextRun,omitempty"`
+	PausedAt    int64             `json:"pausedAt,omitempty"`
 	Executions  []JobExecution    `json:"executions"`
+	Claim       *JobClaim         `json:"claim,omitempty"`
+	TraceParent string            `json:"traceParent,omitempty"`
+	Idempotency *JobIdempotency   `json:"-"`
+	// ReplacedID is the ID of the pending job canceled by adding this one with its unique key, only
+	// set on the job returned by the repository Add
+	ReplacedID string `json:"-"`
 }
 
 // IsExecutable returns whether the job callback should be executed
 func (j *Job) IsExecutable() bool {
-	return j.Status != JobStatusSuccess && j.Status != JobStatusCanceled
+	return j.Status != JobStatusSuccess && j.Status != JobStatusCanceled && j.Status != JobStatusPaused && j.Status != JobStatusSkipped
+}
+
+// IsValidMissedPolicy returns whether the policy for runs missed while paused is one of the known ones
+func IsValidMissedPolicy(policy string) bool {
+	return policy == JobMissedRunOnce || policy == JobMissedRunAll || policy == JobMissedSkip
+}
+
+// DataSize returns the size in bytes of the job Data keys and values
+func (j *Job) DataSize() int {
+	size := 0
+	for k, v := range j.Data {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// ConflictPolicy returns what to do when the job is added with the UniqueKey of another pending
+// job of the same client, JobConflictReject unless OnConflict says otherwise.
+func (j *Job) ConflictPolicy() (string, error) {
+	switch j.OnConflict {
+	case "":
+		return JobConflictReject, nil
+	case JobConflictReject, JobConflictReplace, JobConflictKeepEarliest:
+		return j.OnConflict, nil
+	}
+	return "", fmt.Errorf("invalid job conflict policy: '%s'", j.OnConflict)
 }
 
 // JobSchedule ...
@@ -44,11 +108,41 @@ type JobSchedule struct {
 	Value  string `json:"value"`
 }
 
+// JobIdempotency identifies the request that created a job, so retries of the same request
+// don't create duplicate jobs until ExpiresAt (epoch).
+type JobIdempotency struct {
+	Key         string
+	Fingerprint string
+	ExpiresAt   int64
+}
+
+// IsExpired returns whether the idempotency key can be reused at the given timestamp
+func (i *JobIdempotency) IsExpired(timestamp int64) bool {
+	return timestamp >= i.ExpiresAt
+}
+
+// JobClaim is a lease taken by a scheduler node on the run of a job scheduled at NextRun. The
+// claim expires at ExpiresAt (epoch) unless its Owner renews it, so another node can take the
+// run over. ExecutionID is the same for every claim of the run, so callback receivers can
+// deduplicate it, while Attempt counts the claims taken on it.
+type JobClaim struct {
+	ExecutionID string `json:"executionId"`
+	Owner       string `json:"owner"`
+	ExpiresAt   int64  `json:"expiresAt"`
+	Attempt     int    `json:"attempt"`
+}
+
+// IsExpired returns whether the claim can be taken over at the given timestamp
+func (c *JobClaim) IsExpired(timestamp int64) bool {
+	return timestamp >= c.ExpiresAt
+}
+
 // JobExecution ...
 type JobExecution struct {
 	Timestamp int64
 	Status    string
 	Message   string
+	Trigger   string `json:",omitempty"`
 }
 
 // IsValid checks the JobSchedule values and retrun
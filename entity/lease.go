@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Lease grants its Holder exclusive ownership of a named resource until ExpiresAt. Checkpoint is
+// a progress marker (epoch) the holder advances, so a new holder resumes where the previous one
+// stopped.
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Checkpoint int64     `json:"checkpoint"`
+}
+
+// IsHeldBy returns whether the lease is held by the given holder at the given time
+func (l *Lease) IsHeldBy(holder string, now time.Time) bool {
+	return l.Holder == holder && now.Before(l.ExpiresAt)
+}
+
+// IsExpired returns whether the lease can be acquired by any holder at the given time
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
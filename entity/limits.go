@@ -0,0 +1,19 @@
+package entity
+
+import "fmt"
+
+// ClientLimits restricts the jobs a client can create. Zero values disable the corresponding limit.
+type ClientLimits struct {
+	MaxPendingJobs int     `json:"maxPendingJobs"`
+	CreateRate     float64 `json:"createRate"`
+	CreateBurst    int     `json:"createBurst"`
+	MaxDataSize    int     `json:"maxDataSize"`
+}
+
+// Validate returns an error if any of the limits is negative
+func (l ClientLimits) Validate() error {
+	if l.MaxPendingJobs < 0 || l.CreateRate < 0 || l.CreateBurst < 0 || l.MaxDataSize < 0 {
+		return fmt.Errorf("invalid client limits: negative values")
+	}
+	return nil
+}
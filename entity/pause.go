@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Pause holds the jobs of a client, with callbacks to a host or both, including the jobs created
+// after it, until it's lifted. A blank ClientKey or CallbackHost matches any.
+type Pause struct {
+	ClientKey    string `json:"clientKey,omitempty"`
+	CallbackHost string `json:"callbackHost,omitempty"`
+	PausedAt     int64  `json:"pausedAt"`
+}
+
+// Key identifies the pause by its client and host
+func (p *Pause) Key() string {
+	return p.ClientKey + "/" + strings.ToLower(p.CallbackHost)
+}
+
+// Matches returns whether the pause holds the given job
+func (p *Pause) Matches(job *Job) bool {
+	if p.ClientKey != "" && p.ClientKey != job.ClientKey {
+		return false
+	}
+	if p.CallbackHost == "" {
+		return true
+	}
+	u, err := url.Parse(job.CallbackURL)
+	return err == nil && strings.EqualFold(u.Host, p.CallbackHost)
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// JobCreated ...
+	JobCreated = "created"
+
+	// JobCanceled ...
+	JobCanceled = "canceled"
+
+	// JobPaused ...
+	JobPaused = "paused"
+
+	// JobResumed ...
+	JobResumed = "resumed"
+
+	// JobDispatched is published when a job callback is sent
+	JobDispatched = "dispatched"
+
+	// JobSucceeded is published when a job execution succeeds
+	JobSucceeded = "succeeded"
+
+	// JobFailed is published when a job execution fails
+	JobFailed = "failed"
+)
+
+const (
+	// DefaultHistorySize is the number of latest events kept to resume subscriptions
+	DefaultHistorySize = 1000
+
+	// subscriptionBuffer is the number of events a subscriber can fall behind before it's dropped
+	subscriptionBuffer = 256
+)
+
+// Event is something that happened to a job. IDs are sequential, so a subscriber can resume
+// from the last event it received.
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	JobID     string    `json:"jobId"`
+	ClientKey string    `json:"clientKey"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Publisher publishes job events
+type Publisher interface {
+	Publish(Event) Event
+}
+
+// Filter restricts the events of a subscription. Zero values match any event.
+type Filter struct {
+	JobID     string
+	ClientKey string
+	Status    string
+	Type      string
+}
+
+// Match returns whether the event satisfies all the filter conditions
+func (f *Filter) Match(e *Event) bool {
+	return (f.JobID == "" || f.JobID == e.JobID) &&
+		(f.ClientKey == "" || f.ClientKey == e.ClientKey) &&
+		(f.Status == "" || f.Status == e.Status) &&
+		(f.Type == "" || f.Type == e.Type)
+}
+
+// Bus fans the events out to its subscribers, keeping the latest ones so subscriptions can resume
+type Bus struct {
+	sync.Mutex
+	seq         uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]bool
+	now         func() time.Time
+}
+
+// NewBus returns a Bus keeping the given number of latest events, DefaultHistorySize if not
+// positive
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{historySize: historySize, subscribers: make(map[*Subscription]bool), now: time.Now}
+}
+
+// Publish assigns the event its ID and sends it to the subscribers matching it. Subscribers too
+// far behind are dropped, their channel closed, so publishing never blocks.
+func (b *Bus) Publish(e Event) Event {
+	b.Lock()
+	defer b.Unlock()
+
+	b.seq++
+	e.ID = b.seq
+	if e.Time.IsZero() {
+		e.Time = b.now()
+	}
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for s := range b.subscribers {
+		if !s.filter.Match(&e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			b.drop(s)
+		}
+	}
+	return e
+}
+
+// Subscribe returns a subscription to the events matching the filter. When lastID is given, the
+// events kept after it are sent first.
+func (b *Bus) Subscribe(filter Filter, lastID uint64) *Subscription {
+	b.Lock()
+	defer b.Unlock()
+
+	var backlog []Event
+	if lastID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastID && filter.Match(&e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+	s := &Subscription{bus: b, filter: filter, events: make(chan Event, len(backlog)+subscriptionBuffer)}
+	for _, e := range backlog {
+		s.events <- e
+	}
+	b.subscribers[s] = true
+	return s
+}
+
+// Close ends every subscription, so streams finish on shutdown
+func (b *Bus) Close() {
+	b.Lock()
+	defer b.Unlock()
+	for s := range b.subscribers {
+		b.drop(s)
+	}
+}
+
+// drop removes the subscriber, the caller must hold the lock
+func (b *Bus) drop(s *Subscription) {
+	if b.subscribers[s] {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Subscription receives the events matching its filter
+type Subscription struct {
+	bus    *Bus
+	filter Filter
+	events chan Event
+}
+
+// Events returns the channel receiving the events, closed when the subscription is closed or
+// dropped for falling behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.bus.Lock()
+	defer s.bus.Unlock()
+	s.bus.drop(s)
+}
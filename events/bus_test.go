@@ -0,0 +1,85 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+)
+
+func receive(t *testing.T, s *Subscription, n int) []Event {
+	received := make([]Event, 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case e, open := <-s.Events():
+			if !open {
+				t.Fatalf("subscription closed after %d events, expected %d", i, n)
+			}
+			received = append(received, e)
+		default:
+			t.Fatalf("expected %d events but got %d", n, i)
+		}
+	}
+	select {
+	case e, open := <-s.Events():
+		if open {
+			t.Fatalf("expected %d events but got another one: %+v", n, e)
+		}
+	default:
+	}
+	return received
+}
+
+func Test_Bus_Filter(t *testing.T) {
+	b := NewBus(10)
+	all := b.Subscribe(Filter{}, 0)
+	acme := b.Subscribe(Filter{ClientKey: "acme", Type: JobFailed}, 0)
+
+	b.Publish(Event{Type: JobCreated, JobID: "job-1", ClientKey: "acme"})
+	b.Publish(Event{Type: JobFailed, JobID: "job-1", ClientKey: "acme"})
+	b.Publish(Event{Type: JobFailed, JobID: "job-2", ClientKey: "other"})
+
+	if events := receive(t, all, 3); events[0].ID != 1 || events[2].ID != 3 || events[0].Time.IsZero() {
+		t.Fatalf("expected sequential events with time but got %+v", events)
+	}
+	if events := receive(t, acme, 1); events[0].ID != 2 {
+		t.Fatalf("expected only the failed event of acme but got %+v", events)
+	}
+}
+
+func Test_Bus_Resume(t *testing.T) {
+	b := NewBus(3)
+	for i := 1; i <= 5; i++ {
+		b.Publish(Event{Type: JobCreated, JobID: fmt.Sprintf("job-%d", i)})
+	}
+
+	if events := receive(t, b.Subscribe(Filter{}, 3), 2); events[0].ID != 4 || events[1].ID != 5 {
+		t.Fatalf("expected events after 3 but got %+v", events)
+	}
+	if events := receive(t, b.Subscribe(Filter{}, 1), 3); events[0].ID != 3 {
+		t.Fatalf("expected the kept events after 1 but got %+v", events)
+	}
+	receive(t, b.Subscribe(Filter{}, 0), 0)
+}
+
+func Test_Bus_DropsSlowSubscribers(t *testing.T) {
+	b := NewBus(10)
+	slow := b.Subscribe(Filter{}, 0)
+	for i := 0; i <= subscriptionBuffer; i++ {
+		b.Publish(Event{Type: JobCreated})
+	}
+
+	receive(t, slow, subscriptionBuffer)
+	if len(b.subscribers) != 0 {
+		t.Fatalf("expected slow subscriber to be dropped")
+	}
+}
+
+func Test_Bus_Close(t *testing.T) {
+	b := NewBus(10)
+	s := b.Subscribe(Filter{}, 0)
+	b.Close()
+	s.Close()
+
+	if _, open := <-s.Events(); open {
+		t.Fatalf("expected subscription to be closed")
+	}
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// Jobs is a repository.Jobs decorator publishing the events of the jobs changed through it
+type Jobs struct {
+	repository.Jobs
+	publisher Publisher
+}
+
+// NewJobs returns a Jobs decorator over the given repository
+func NewJobs(r repository.Jobs, p Publisher) *Jobs {
+	return &Jobs{Jobs: r, publisher: p}
+}
+
+// JobEvent returns an event of the given type for the job
+func JobEvent(eventType string, job *entity.Job, message string) Event {
+	return Event{Type: eventType, JobID: job.ID, ClientKey: job.ClientKey, Status: job.Status, Message: message}
+}
+
+// ExecutionEvent returns the type of the event of an execution with the given status
+func ExecutionEvent(status string) string {
+	if status == entity.JobStatusSuccess {
+		return JobSucceeded
+	}
+	return JobFailed
+}
+
+func (j *Jobs) publish(eventType string, job entity.Job, err error, message string) {
+	if err == nil {
+		j.publisher.Publish(JobEvent(eventType, &job, message))
+	}
+}
+
+// publishAdded publishes the created event of an added job, preceded by the canceled event of
+// the pending job it replaced, if any
+func (j *Jobs) publishAdded(job entity.Job, err error) {
+	if err == nil && job.ReplacedID != "" {
+		replaced := entity.Job{ID: job.ReplacedID, ClientKey: job.ClientKey, Status: entity.JobStatusCanceled}
+		j.publisher.Publish(JobEvent(JobCanceled, &replaced, "replaced by job "+job.ID))
+	}
+	j.publish(JobCreated, job, err, "")
+}
+
+// Add ...
+func (j *Jobs) Add(job entity.Job) (entity.Job, error) {
+	job, err := j.Jobs.Add(job)
+	j.publishAdded(job, err)
+	return job, err
+}
+
+// AddAll ...
+func (j *Jobs) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	res, errs := j.Jobs.AddAll(jobs)
+	for i := range res {
+		j.publishAdded(res[i], errs[i])
+	}
+	return res, errs
+}
+
+// Cancel ...
+func (j *Jobs) Cancel(jobID string) (entity.Job, error) {
+	job, err := j.Jobs.Cancel(jobID)
+	j.publish(JobCanceled, job, err, "")
+	return job, err
+}
+
+// Pause ...
+func (j *Jobs) Pause(jobID string, now time.Time) (entity.Job, error) {
+	job, err := j.Jobs.Pause(jobID, now)
+	j.publish(JobPaused, job, err, "")
+	return job, err
+}
+
+// Resume ...
+func (j *Jobs) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	job, err := j.Jobs.Resume(jobID, missed, now)
+	j.publish(JobResumed, job, err, "")
+	return job, err
+}
+
+// AddExecution ...
+func (j *Jobs) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
+	job, err := j.Jobs.AddExecution(jobID, date, status, message)
+	j.publish(ExecutionEvent(status), job, err, message)
+	return job, err
+}
+
+// AppendExecution ...
+func (j *Jobs) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	job, err := j.Jobs.AppendExecution(jobID, execution)
+	j.publish(ExecutionEvent(execution.Status), job, err, execution.Message)
+	return job, err
+}
+
+// Complete ...
+func (j *Jobs) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	job, err := j.Jobs.Complete(jobID, owner, date, status, message)
+	j.publish(ExecutionEvent(status), job, err, message)
+	return job, err
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+func Test_Jobs_PublishesEvents(t *testing.T) {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	b := NewBus(10)
+	jobs := NewJobs(repo, b)
+	s := b.Subscribe(Filter{}, 0)
+
+	job, err := jobs.Add(entity.Job{
+		ClientKey: "acme",
+		Schedule:  entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: "1234567890"},
+	})
+	if err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+	jobs.AppendExecution(job.ID, entity.JobExecution{Timestamp: 1234567890, Status: entity.JobStatusError, Message: "timeout"})
+	jobs.Cancel(job.ID)
+	jobs.Cancel("unknown")
+	jobs.AddExecution(job.ID, time.Now(), entity.JobStatusSuccess, "")
+
+	events := receive(t, s, 4)
+	expected := []string{JobCreated, JobFailed, JobCanceled, JobSucceeded}
+	for i, e := range events {
+		if e.Type != expected[i] || e.JobID != job.ID || e.ClientKey != "acme" {
+			t.Fatalf("expected %s event of job %s but got %+v", expected[i], job.ID, e)
+		}
+	}
+	if events[1].Message != "timeout" || events[2].Status != entity.JobStatusCanceled {
+		t.Fatalf("unexpected event details: %+v", events)
+	}
+}
+
+func Test_Jobs_PublishesReplacedJobCanceled(t *testing.T) {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	b := NewBus(10)
+	jobs := NewJobs(repo, b)
+	s := b.Subscribe(Filter{}, 0)
+
+	job := entity.Job{
+		ClientKey: "acme",
+		UniqueKey: "order-123",
+		Schedule:  entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: "1234567890"},
+	}
+	first, _ := jobs.Add(job)
+	job.OnConflict = entity.JobConflictReplace
+	second, err := jobs.Add(job)
+	if err != nil {
+		t.Fatalf("unable to replace job: %v", err)
+	}
+
+	events := receive(t, s, 3)
+	if events[1].Type != JobCanceled || events[1].JobID != first.ID || events[1].Status != entity.JobStatusCanceled {
+		t.Fatalf("expected canceled event of the replaced job but got %+v", events[1])
+	}
+	if events[2].Type != JobCreated || events[2].JobID != second.ID {
+		t.Fatalf("expected created event of the new job but got %+v", events[2])
+	}
+}
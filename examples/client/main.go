@@ -1,34 +1,26 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
-)
-
-// Job ...
-type Job struct {
-	CallbackURL string      `json:"callbackURL"`
-	Schedule    JobSchedule `json:"schedule"`
-}
 
-// JobSchedule ...
-type JobSchedule struct {
-	Format string `json:"format"`
-	Value  string `json:"value"`
-}
+	"github.com/marcoshack/schedula/client"
+	"github.com/marcoshack/schedula/entity"
+)
 
 // ClientConfig ...
 type ClientConfig struct {
 	TotalCallbacks  int
 	CallbackBaseURL *url.URL
 	CallbackAddr    string
-	JobsResourceURL *url.URL
+	ServerBaseURL   string
+	APIKey          string
 	CallbackDelta   time.Duration
 	CallbackTime    time.Time
 	ResponseDelay   time.Duration
@@ -46,6 +38,7 @@ var (
 	callbackPort  = flag.Int("p", 8088, "TCP `port` number to listen for HTTP callbacks")
 	callbackAddr  = flag.String("b", "127.0.0.1", "IP `address` to listen for HTTP callbacks")
 	serverBaseURL = flag.String("s", "http://localhost:8080/", "Schedula server base `URL`")
+	apiKey        = flag.String("k", os.Getenv("SCHEDULA_API_KEY"), "API `key`, defaults to $SCHEDULA_API_KEY")
 	callbackDelta = flag.Int("delta", 5, "delta in `seconds` from the current time to callbacks time")
 	responseDelay = flag.Int("delay", 0, "delay in `milliseconds` to respond to callback request")
 	timeout       = flag.Int("timeout", 30, "maximum number of `seconds` after callback time to wait for callbacks")
@@ -53,10 +46,6 @@ var (
 
 func loadConfig() *ClientConfig {
 	flag.Parse()
-	serverURL, err := url.Parse(fmt.Sprintf("%sjobs/", *serverBaseURL))
-	if err != nil {
-		log.Fatalf("ERROR: invalid server base URL: %v", err)
-	}
 	callbackURL, err := url.Parse(fmt.Sprintf("http://%s:%d/callback/", *callbackAddr, *callbackPort))
 	if err != nil {
 		log.Fatalf("ERROR: invalid callback URL: %v", err)
@@ -69,7 +58,8 @@ func loadConfig() *ClientConfig {
 		TotalCallbacks:  *nCallbacks,
 		CallbackAddr:    fmt.Sprintf("%s:%d", *callbackAddr, *callbackPort),
 		CallbackBaseURL: callbackURL,
-		JobsResourceURL: serverURL,
+		ServerBaseURL:   *serverBaseURL,
+		APIKey:          *apiKey,
 		CallbackDelta:   delta,
 		CallbackTime:    time.Now().Add(delta),
 		ResponseDelay:   delay,
@@ -87,52 +77,34 @@ func startCallbackServer(conf *ClientConfig, callbacks chan int) {
 	go server.ListenAndServe()
 }
 
-func checkServer(conf *ClientConfig, client *http.Client) {
-	_, err := client.Head(conf.JobsResourceURL.String())
-	if err != nil {
+func checkServer(c *client.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := c.List(ctx, client.JobQuery{Limit: 1}); err != nil {
 		log.Fatalf("ERROR: schedula server is unavailable: %v", err)
 	}
 }
 
 func main() {
 	conf := loadConfig()
-	client := &http.Client{}
-	checkServer(conf, client)
+	c, err := client.New(client.Config{BaseURL: conf.ServerBaseURL, APIKey: conf.APIKey, MaxRetries: 3})
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	checkServer(c)
 
 	created := 0
 	start := time.Now()
 	for i := 1; i <= conf.TotalCallbacks; i++ {
-		job := &Job{
+		job := entity.Job{
 			CallbackURL: conf.CallbackURL(fmt.Sprintf("?id=%d", i)).String(),
-			Schedule: JobSchedule{
-				Format: "timestamp",
+			Schedule: entity.JobSchedule{
+				Format: entity.ScheduleFormatTimestamp,
 				Value:  fmt.Sprintf("%v", conf.CallbackTime.Unix()),
 			},
 		}
-
-		var body = new(bytes.Buffer)
-		err := json.NewEncoder(body).Encode(job)
-		if err != nil {
-			log.Printf("ERROR: Unable to encode request body: %v", err)
-			continue
-		}
-
-		req, err := http.NewRequest("POST", conf.JobsResourceURL.String(), body)
-		if err != nil {
-			log.Printf("ERROR: Failed to create HTTP request: %v", err)
-			continue
-		}
-		req.Header.Set("User-Agent", "schedula-client")
-		req.Header.Set("Content-Type", "application/json")
-
-		res, err := client.Do(req)
-		if err != nil {
-			log.Printf("ERROR: Failed to send HTTP request: %v", err)
-			continue
-		}
-
-		if res.StatusCode != http.StatusCreated {
-			log.Printf("ERROR: Invalid response code, expected 201 Created but got %s", res.Status)
+		if _, err := c.Create(context.Background(), job); err != nil {
+			log.Printf("ERROR: Unable to create job: %v", err)
 			continue
 		}
 		created++
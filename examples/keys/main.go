@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// APIKey ...
+type APIKey struct {
+	ID        string `json:"id"`
+	Secret    string `json:"secret,omitempty"`
+	ClientKey string `json:"clientKey"`
+	Role      string `json:"role"`
+	CreatedAt int64  `json:"createdAt"`
+	Revoked   bool   `json:"revoked"`
+}
+
+var (
+	serverBaseURL = flag.String("s", "http://localhost:8080/", "Schedula server base `URL`")
+	adminKey      = flag.String("k", os.Getenv("SCHEDULA_API_KEY"), "admin API `key`, defaults to $SCHEDULA_API_KEY")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <command> [arguments]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  issue <clientKey> [client|admin]  issue an API key for the given client\n")
+	fmt.Fprintf(os.Stderr, "  revoke <id>                       revoke the API key with the given ID\n")
+	fmt.Fprintf(os.Stderr, "  list                              list all API keys\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch {
+	case args[0] == "issue" && (len(args) == 2 || len(args) == 3):
+		role := "client"
+		if len(args) == 3 {
+			role = args[2]
+		}
+		var key APIKey
+		call("POST", "", APIKey{ClientKey: args[1], Role: role}, http.StatusCreated, &key)
+		fmt.Printf("ID:     %s\nClient: %s\nRole:   %s\nSecret: %s\n", key.ID, key.ClientKey, key.Role, key.Secret)
+	case args[0] == "revoke" && len(args) == 2:
+		var key APIKey
+		call("DELETE", args[1], nil, http.StatusOK, &key)
+		fmt.Printf("API key %s of client '%s' revoked\n", key.ID, key.ClientKey)
+	case args[0] == "list" && len(args) == 1:
+		var keys []APIKey
+		call("GET", "", nil, http.StatusOK, &keys)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCLIENT\tROLE\tCREATED\tREVOKED")
+		for _, key := range keys {
+			created := time.Unix(key.CreatedAt, 0).Format(time.RFC3339)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\n", key.ID, key.ClientKey, key.Role, created, key.Revoked)
+		}
+		w.Flush()
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func call(method string, id string, reqBody interface{}, expectedStatus int, resBody interface{}) {
+	var body = new(bytes.Buffer)
+	if reqBody != nil {
+		if err := json.NewEncoder(body).Encode(reqBody); err != nil {
+			log.Fatalf("ERROR: Unable to encode request body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%sadmin/keys/%s", *serverBaseURL, id), body)
+	if err != nil {
+		log.Fatalf("ERROR: Failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("User-Agent", "schedula-keys")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", *adminKey))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("ERROR: Failed to send HTTP request: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expectedStatus {
+		var errRes struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&errRes)
+		log.Fatalf("ERROR: Invalid response code, expected %d but got %s: %s", expectedStatus, res.Status, errRes.Error)
+	}
+	if err := json.NewDecoder(res.Body).Decode(resBody); err != nil {
+		log.Fatalf("ERROR: Unable to decode response body: %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marcoshack/schedula/client"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+)
+
+// dataFlag collects the repeated -data key=value flags
+type dataFlag map[string]string
+
+func (d dataFlag) String() string {
+	return ""
+}
+
+func (d dataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value but got '%s'", value)
+	}
+	d[key] = val
+	return nil
+}
+
+func createCommand(c *client.Client, args []string) {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	file := flags.String("f", "", "JSON `file` of the job, - for stdin. The other flags override its fields")
+	callbackURL := flags.String("callback", "", "callback `URL`")
+	at := flags.String("at", "", "`time` to run the job: epoch timestamp, RFC 3339 time or +duration from now, e.g. +10m")
+	clientKey := flags.String("client", "", "client `key` of the job, only honored for admin keys")
+	uniqueKey := flags.String("unique-key", "", "unique `key` among the pending jobs of the client")
+	onConflict := flags.String("on-conflict", "", "`policy` for unique key conflicts: reject, replace, keep-earliest")
+	idempotencyKey := flags.String("idempotency-key", "", "idempotency `key`, a random one if blank")
+	output := flags.String("o", "json", "output `format`: json, id")
+	data := dataFlag{}
+	flags.Var(data, "data", "job data `key=value`, can be repeated")
+	flags.Parse(args)
+
+	var job entity.Job
+	if *file != "" {
+		job = readJobFile(*file)
+	}
+	if *callbackURL != "" {
+		job.CallbackURL = *callbackURL
+	}
+	if *at != "" {
+		timestamp, err := parseTime(*at)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		job.Schedule = entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: strconv.FormatInt(timestamp, 10)}
+	}
+	if *clientKey != "" {
+		job.ClientKey = *clientKey
+	}
+	if *uniqueKey != "" {
+		job.UniqueKey = *uniqueKey
+	}
+	if *onConflict != "" {
+		job.OnConflict = *onConflict
+	}
+	for key, value := range data {
+		if job.Data == nil {
+			job.Data = map[string]string{}
+		}
+		job.Data[key] = value
+	}
+	if job.CallbackURL == "" || job.Schedule.Value == "" {
+		fatalf("A callback URL and a schedule are required, use -callback and -at or -f")
+	}
+	if *idempotencyKey == "" {
+		*idempotencyKey = client.NewIdempotencyKey()
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	created, err := c.CreateWithKey(ctx, *idempotencyKey, job)
+	if err != nil {
+		fatalf("Unable to create job: %v", err)
+	}
+	if *output == "id" {
+		fmt.Println(created.ID)
+		return
+	}
+	printJSON(created)
+}
+
+func readJobFile(path string) entity.Job {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fatalf("Unable to read job file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	var job entity.Job
+	if err := json.NewDecoder(r).Decode(&job); err != nil {
+		fatalf("Invalid job file: %v", err)
+	}
+	return job
+}
+
+// parseTime parses an epoch timestamp, a RFC 3339 time or a +duration from now
+func parseTime(value string) (int64, error) {
+	if strings.HasPrefix(value, "+") {
+		d, err := time.ParseDuration(value[1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid time: '%s'", value)
+		}
+		return time.Now().Add(d).Unix(), nil
+	}
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return timestamp, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: '%s'", value)
+	}
+	return t.Unix(), nil
+}
+
+func getCommand(c *client.Client, args []string) {
+	if len(args) != 1 {
+		usage()
+		os.Exit(2)
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	job, err := c.Get(ctx, args[0])
+	if err != nil {
+		fatalf("Unable to get job: %v", err)
+	}
+	printJSON(job)
+}
+
+func listCommand(c *client.Client, args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	status := flags.String("status", "", "job `status`: pending, paused, success, error, canceled, skipped")
+	clientKey := flags.String("client", "", "client `key`")
+	callbackHost := flags.String("callback-host", "", "callback `host`")
+	sortBy := flags.String("sort", "", "sort `field`: createdAt, nextRun")
+	order := flags.String("order", "", "sort `order`: asc, desc")
+	limit := flags.Int("limit", 50, "`number` of jobs per page")
+	cursor := flags.String("cursor", "", "`cursor` of the page to list, printed after the previous one")
+	all := flags.Bool("all", false, "list every page")
+	output := flags.String("o", "table", "output `format`: table, json")
+	flags.Parse(args)
+
+	query := client.JobQuery{
+		Filter:    client.JobFilter{Status: *status, ClientKey: *clientKey, CallbackHost: *callbackHost},
+		SortBy:    *sortBy,
+		SortOrder: *order,
+		Cursor:    *cursor,
+		Limit:     *limit,
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	var jobs []entity.Job
+	nextCursor := ""
+	if *all {
+		err := c.ListAll(ctx, query, func(job entity.Job) error {
+			jobs = append(jobs, job)
+			return nil
+		})
+		if err != nil {
+			fatalf("Unable to list jobs: %v", err)
+		}
+	} else {
+		page, err := c.List(ctx, query)
+		if err != nil {
+			fatalf("Unable to list jobs: %v", err)
+		}
+		jobs, nextCursor = page.Jobs, page.NextCursor
+	}
+
+	if *output == "json" {
+		printJSON(jobs)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCLIENT\tSTATUS\tNEXT RUN\tCALLBACK")
+		for _, job := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.ID, job.ClientKey, job.Status, formatTime(job.NextRun), job.CallbackURL)
+		}
+		w.Flush()
+	}
+	if nextCursor != "" {
+		fmt.Fprintf(os.Stderr, "More jobs available, next page with: -cursor %s\n", nextCursor)
+	}
+}
+
+func cancelCommand(c *client.Client, args []string) {
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	failed := false
+	for _, id := range args {
+		if err := c.Cancel(ctx, id); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Unable to cancel job %s: %v\n", id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Job %s canceled\n", id)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runCommand(c *client.Client, args []string) {
+	if len(args) != 1 {
+		usage()
+		os.Exit(2)
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	execution, err := c.Run(ctx, args[0])
+	if err != nil {
+		fatalf("Unable to run job: %v", err)
+	}
+	printJSON(execution)
+}
+
+func executionsCommand(c *client.Client, args []string) {
+	flags := flag.NewFlagSet("executions", flag.ExitOnError)
+	status := flags.String("status", "", "execution `status`: success, error")
+	limit := flags.Int("limit", 20, "`number` of executions, newest first")
+	cursor := flags.String("cursor", "", "`cursor` of the page to list, printed after the previous one")
+	output := flags.String("o", "table", "output `format`: table, json")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	page, err := c.Executions(ctx, client.ExecutionQuery{JobID: flags.Arg(0), Status: *status, Cursor: *cursor, Limit: *limit})
+	if err != nil {
+		fatalf("Unable to list executions: %v", err)
+	}
+	if *output == "json" {
+		printJSON(page.Executions)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tSTATUS\tTRIGGER\tMESSAGE")
+		for _, e := range page.Executions {
+			trigger := e.Trigger
+			if trigger == "" {
+				trigger = "schedule"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", formatTime(e.Timestamp), e.Status, trigger, e.Message)
+		}
+		w.Flush()
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "More executions available, next page with: -cursor %s\n", page.NextCursor)
+	}
+}
+
+func tailCommand(c *client.Client, args []string) {
+	flags := flag.NewFlagSet("tail", flag.ExitOnError)
+	jobID := flags.String("job", "", "job `ID`")
+	clientKey := flags.String("client", "", "client `key`")
+	status := flags.String("status", "", "job `status`")
+	eventType := flags.String("type", "", "event `type`: created, canceled, paused, resumed, dispatched, succeeded, failed")
+	from := flags.Uint64("from", 0, "event `ID` to resume after, as long as the server still keeps the events after it")
+	output := flags.String("o", "text", "output `format`: text, json")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	filter := events.Filter{JobID: *jobID, ClientKey: *clientKey, Status: *status, Type: *eventType}
+	show := func(e events.Event) error {
+		if *output == "json" {
+			data, _ := json.Marshal(e)
+			fmt.Println(string(data))
+			return nil
+		}
+		fmt.Printf("%s  %-10s  job=%s client=%s status=%s %s\n", e.Time.Format(time.RFC3339), e.Type, e.JobID, e.ClientKey, e.Status, e.Message)
+		return nil
+	}
+
+	// the stream is resumed after the last event received whenever it's interrupted
+	lastID := *from
+	for {
+		var err error
+		lastID, err = c.Events(ctx, filter, lastID, show)
+		if ctx.Err() != nil {
+			return
+		}
+		if client.IsUnauthorized(err) || client.IsNotFound(err) {
+			fatalf("Unable to follow events: %v", err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARN: Event stream interrupted, reconnecting: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func formatTime(timestamp int64) string {
+	if timestamp == 0 {
+		return "-"
+	}
+	return time.Unix(timestamp, 0).Format(time.RFC3339)
+}
+
+func printJSON(value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fatalf("Unable to encode output: %v", err)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/marcoshack/schedula/client"
+)
+
+var (
+	serverURL   = flag.String("s", "", "Schedula server base `URL`, overrides the profile one")
+	apiKey      = flag.String("k", "", "API `key`, overrides $SCHEDULA_API_KEY and the profile one")
+	profileName = flag.String("profile", "", "`name` of the profile to use, defaults to $SCHEDULACTL_PROFILE or the current one")
+	configPath  = flag.String("config", "", "profiles `file`, defaults to $SCHEDULACTL_CONFIG or ~/.schedulactl.json")
+	timeout     = flag.Duration("timeout", 30*time.Second, "time `duration` to wait for each command, except tail")
+	retries     = flag.Int("retries", 2, "`number` of retries of failed requests safe to repeat")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <command> [arguments]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  create [flags]                create a job from flags or a JSON file\n")
+	fmt.Fprintf(os.Stderr, "  get <id>                      show a job\n")
+	fmt.Fprintf(os.Stderr, "  list [flags]                  list jobs, filtered by status, client or callback host\n")
+	fmt.Fprintf(os.Stderr, "  cancel <id>...                cancel jobs\n")
+	fmt.Fprintf(os.Stderr, "  run-now <id>                  execute a job callback right away\n")
+	fmt.Fprintf(os.Stderr, "  executions [flags] <id>       list the executions of a job\n")
+	fmt.Fprintf(os.Stderr, "  tail [flags]                  follow the job events\n")
+	fmt.Fprintf(os.Stderr, "  profile list                  list the profiles\n")
+	fmt.Fprintf(os.Stderr, "  profile set <name>            save the -s and -k options as a profile\n")
+	fmt.Fprintf(os.Stderr, "  profile use <name>            make a profile the current one\n")
+	fmt.Fprintf(os.Stderr, "  profile delete <name>         delete a profile\n\n")
+	fmt.Fprintf(os.Stderr, "Run '%s <command> -h' for the command flags.\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	command, args := args[0], args[1:]
+	if command == "profile" {
+		profileCommand(args)
+		return
+	}
+	commands := map[string]func(*client.Client, []string){
+		"create":     createCommand,
+		"get":        getCommand,
+		"list":       listCommand,
+		"cancel":     cancelCommand,
+		"run-now":    runCommand,
+		"executions": executionsCommand,
+		"tail":       tailCommand,
+	}
+	run, exists := commands[command]
+	if !exists {
+		usage()
+		os.Exit(2)
+	}
+	run(newClient(), args)
+}
+
+func newClient() *client.Client {
+	profile := loadProfiles().active()
+	c, err := client.New(client.Config{BaseURL: profile.Server, APIKey: profile.APIKey, MaxRetries: *retries})
+	if err != nil {
+		fatalf("%v", err)
+	}
+	return c
+}
+
+// commandContext returns the context of a command, done after the -timeout duration
+func commandContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), *timeout)
+}
+
+func fatalf(format string, args ...interface{}) {
+	log.Fatalf("ERROR: "+format, args...)
+}
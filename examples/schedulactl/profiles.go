@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+)
+
+// defaultServer is the server of profiles without one
+const defaultServer = "http://localhost:8080/"
+
+// Profile is a Schedula server and the API key to call it with
+type Profile struct {
+	Server string `json:"server"`
+	APIKey string `json:"apiKey,omitempty"`
+}
+
+// Profiles are the saved profiles and the one used by default
+type Profiles struct {
+	Current  string             `json:"current"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// profilesPath returns the path of the profiles file: the -config flag, $SCHEDULACTL_CONFIG or
+// ~/.schedulactl.json
+func profilesPath() string {
+	if *configPath != "" {
+		return *configPath
+	}
+	if path := os.Getenv("SCHEDULACTL_CONFIG"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".schedulactl.json")
+}
+
+func loadProfiles() *Profiles {
+	profiles := &Profiles{Profiles: map[string]Profile{}}
+	data, err := os.ReadFile(profilesPath())
+	if os.IsNotExist(err) {
+		return profiles
+	}
+	if err != nil {
+		fatalf("Unable to read profiles: %v", err)
+	}
+	if err := json.Unmarshal(data, profiles); err != nil {
+		fatalf("Invalid profiles file '%s': %v", profilesPath(), err)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = map[string]Profile{}
+	}
+	return profiles
+}
+
+func (p *Profiles) save() {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		fatalf("Unable to encode profiles: %v", err)
+	}
+	if err := os.WriteFile(profilesPath(), append(data, '\n'), 0600); err != nil {
+		fatalf("Unable to write profiles: %v", err)
+	}
+}
+
+// active returns the profile selected by the -profile flag, $SCHEDULACTL_PROFILE or the current
+// one, with the -s and -k flags and $SCHEDULA_API_KEY taking precedence over its values
+func (p *Profiles) active() Profile {
+	name := *profileName
+	if name == "" {
+		name = os.Getenv("SCHEDULACTL_PROFILE")
+	}
+	if name == "" {
+		name = p.Current
+	}
+	profile, exists := p.Profiles[name]
+	if name != "" && !exists && name != "default" {
+		fatalf("Unknown profile '%s'", name)
+	}
+	if *serverURL != "" {
+		profile.Server = *serverURL
+	}
+	if profile.Server == "" {
+		profile.Server = defaultServer
+	}
+	if key := os.Getenv("SCHEDULA_API_KEY"); key != "" {
+		profile.APIKey = key
+	}
+	if *apiKey != "" {
+		profile.APIKey = *apiKey
+	}
+	return profile
+}
+
+// profileCommand lists, saves, selects and deletes profiles
+func profileCommand(args []string) {
+	profiles := loadProfiles()
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		names := make([]string, 0, len(profiles.Profiles))
+		for name := range profiles.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tAPI KEY")
+		for _, name := range names {
+			current := ""
+			if name == profiles.Current {
+				current = "*"
+			}
+			key := ""
+			if profiles.Profiles[name].APIKey != "" {
+				key = "********"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, profiles.Profiles[name].Server, key)
+		}
+		w.Flush()
+	case len(args) == 2 && args[0] == "set":
+		// the server and key come from the global -s and -k flags
+		profile := profiles.Profiles[args[1]]
+		if *serverURL != "" {
+			profile.Server = *serverURL
+		}
+		if *apiKey != "" {
+			profile.APIKey = *apiKey
+		}
+		if profile.Server == "" {
+			profile.Server = defaultServer
+		}
+		profiles.Profiles[args[1]] = profile
+		if profiles.Current == "" {
+			profiles.Current = args[1]
+		}
+		profiles.save()
+		fmt.Printf("Profile '%s' saved\n", args[1])
+	case len(args) == 2 && args[0] == "use":
+		if _, exists := profiles.Profiles[args[1]]; !exists {
+			fatalf("Unknown profile '%s'", args[1])
+		}
+		profiles.Current = args[1]
+		profiles.save()
+		fmt.Printf("Using profile '%s'\n", args[1])
+	case len(args) == 2 && args[0] == "delete":
+		delete(profiles.Profiles, args[1])
+		if profiles.Current == args[1] {
+			profiles.Current = ""
+		}
+		profiles.save()
+		fmt.Printf("Profile '%s' deleted\n", args[1])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
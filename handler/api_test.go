@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+// testAPI serves the handlers behind an enabled Authenticator, routed like the server does
+type testAPI struct {
+	handler   http.Handler
+	jobs      repository.Jobs
+	scheduler *stubScheduler
+	bus       *events.Bus
+	// secrets holds the API key secrets of the admin and of the 'acme' and 'other' clients
+	secrets map[string]string
+}
+
+type stubScheduler struct {
+	status scheduler.Status
+	hosts  []scheduler.HostStatus
+	runErr error
+}
+
+func (s *stubScheduler) Run(job entity.Job) (entity.JobExecution, error) {
+	if s.runErr != nil {
+		return entity.JobExecution{}, s.runErr
+	}
+	return entity.JobExecution{Timestamp: time.Now().Unix(), Status: entity.JobStatusSuccess, Trigger: entity.JobTriggerManual}, nil
+}
+
+func (s *stubScheduler) Status() scheduler.Status {
+	return s.status
+}
+
+func (s *stubScheduler) Hosts() []scheduler.HostStatus {
+	return s.hosts
+}
+
+type stubReloader struct{}
+
+func (stubReloader) Reload() (ReloadResult, error) {
+	return ReloadResult{Applied: []string{"workers"}, RestartRequired: []string{}}, nil
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	keys, _ := repository.NewAPIKeys("in-memory")
+	pauses, _ := repository.NewPauses("in-memory")
+	api := &testAPI{
+		scheduler: &stubScheduler{status: scheduler.Status{Running: true, LastTick: time.Now()}},
+		bus:       events.NewBus(100),
+		secrets:   make(map[string]string),
+	}
+	quotas := quota.NewJobs(events.NewJobs(repo, api.bus), entity.ClientLimits{})
+	api.jobs = quotas
+	for name, key := range map[string]entity.APIKey{
+		"admin": {Role: entity.RoleAdmin},
+		"acme":  {Role: entity.RoleClient, ClientKey: "acme"},
+		"other": {Role: entity.RoleClient, ClientKey: "other"},
+	} {
+		key, err := keys.Add(key)
+		if err != nil {
+			t.Fatalf("unable to add API key: %v", err)
+		}
+		api.secrets[name] = key.Secret
+	}
+
+	jobs := NewJobsHandler("/jobs/", api.jobs, JobsConfig{Pauses: pauses, IdempotencyWindow: time.Hour})
+	apiKeys := NewAPIKeysHandler("/admin/keys/", keys)
+	limits := NewLimitsHandler(quotas)
+	run := NewRunHandler(api.jobs, api.scheduler)
+	health := NewHealthHandler(repo, api.scheduler, HealthConfig{Version: "test", RepositoryType: "in-memory", SchedulerType: "ticker"})
+	router := mux.NewRouter()
+	router.HandleFunc("/jobs/", jobs.List).Methods("GET")
+	router.HandleFunc("/jobs/", jobs.Create).Methods("POST")
+	router.HandleFunc("/jobs/batch", jobs.CreateBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/cancel", jobs.CancelBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/pause", jobs.PauseBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/resume", jobs.ResumeBatch).Methods("POST")
+	router.HandleFunc("/jobs/{id}", jobs.Find).Methods("GET")
+	router.HandleFunc("/jobs/{id}", jobs.Delete).Methods("DELETE")
+	router.HandleFunc("/jobs/{id}/pause", jobs.Pause).Methods("POST")
+	router.HandleFunc("/jobs/{id}/resume", jobs.Resume).Methods("POST")
+	router.HandleFunc("/jobs/{id}/run", run.Run).Methods("POST")
+	router.HandleFunc("/jobs/{id}/executions", jobs.Executions).Methods("GET")
+	router.HandleFunc("/pauses", jobs.Pauses).Methods("GET")
+	router.HandleFunc("/events", NewEventsHandler(api.bus).Stream).Methods("GET")
+	router.HandleFunc("/admin/keys/", apiKeys.List).Methods("GET")
+	router.HandleFunc("/admin/keys/", apiKeys.Create).Methods("POST")
+	router.HandleFunc("/admin/keys/{id}", apiKeys.Revoke).Methods("DELETE")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Find).Methods("GET")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Update).Methods("PUT")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Delete).Methods("DELETE")
+	router.HandleFunc("/admin/hosts/", NewHostsHandler(api.scheduler).List).Methods("GET")
+	router.HandleFunc("/admin/status", health.Status).Methods("GET")
+	router.HandleFunc("/admin/reload", NewReloadHandler(stubReloader{}).Reload).Methods("POST")
+	router.HandleFunc("/metrics", NewMetricsHandler(http.NotFoundHandler()).Scrape).Methods("GET")
+
+	root := mux.NewRouter()
+	root.HandleFunc("/healthz", health.Live).Methods("GET")
+	root.HandleFunc("/readyz", health.Ready).Methods("GET")
+	root.PathPrefix("/").Handler(NewAuthenticator(keys, true).Wrap(router))
+	api.handler = root
+	return api
+}
+
+// do sends the request with the API key of the given caller, blank for none
+func (api *testAPI) do(caller string, method string, path string, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	if caller != "" {
+		r.Header.Set("Authorization", "Bearer "+api.secrets[caller])
+	}
+	return api.serve(r)
+}
+
+func (api *testAPI) serve(r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	api.handler.ServeHTTP(w, r)
+	return w
+}
+
+// createJob creates a job of the caller's client, returning its ID
+func (api *testAPI) createJob(t *testing.T, caller string) string {
+	w := api.do(caller, "POST", "/jobs/", testJobBody(caller))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unable to create job: %d %s", w.Code, w.Body.String())
+	}
+	return strings.TrimPrefix(w.Header().Get("Location"), "/jobs/")
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, status int, method string, path string) {
+	if w.Code != status {
+		t.Fatalf("expected %d from %s %s but got %d: %s", status, method, path, w.Code, w.Body.String())
+	}
+}
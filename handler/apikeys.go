@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// APIKeys is a HTTP handler to issue, list and revoke API keys. All its actions require the admin role.
+type APIKeys struct {
+	path       string
+	repository repository.APIKeys
+}
+
+// NewAPIKeysHandler ...
+func NewAPIKeysHandler(path string, repo repository.APIKeys) *APIKeys {
+	return &APIKeys{repository: repo, path: path}
+}
+
+// List all API keys, without their secrets
+func (h *APIKeys) List(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	keys, err := h.repository.List()
+	if err != nil {
+		ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, keys, http.StatusOK)
+}
+
+// Create issues an API key for the 'clientKey' and 'role' given in the JSON request body. The
+// response holds the key secret, which can't be retrieved afterwards.
+func (h *APIKeys) Create(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var key entity.APIKey
+	if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	if key.Role == "" {
+		key.Role = entity.RoleClient
+	}
+	if key.ClientKey == "" && key.Role == entity.RoleClient {
+		ErrorResponse(w, fmt.Errorf("clientKey is required"), http.StatusBadRequest)
+		return
+	}
+	key.Secret = ""
+
+	newKey, err := h.repository.Add(key)
+	if err != nil {
+		logger.Warn("error issuing API key", logging.KeyError, err)
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	logger.Info("API key issued", "key_id", newKey.ID, logging.KeyClientKey, newKey.ClientKey, "role", newKey.Role)
+
+	w.Header().Add("Location", fmt.Sprintf("%s%s", h.path, newKey.ID))
+	writeJSON(w, newKey, http.StatusCreated)
+}
+
+// Revoke invalidates the API key specified by the 'id' path parameter
+func (h *APIKeys) Revoke(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	key, err := h.repository.Revoke(mux.Vars(r)["id"])
+	if err != nil {
+		ErrorResponse(w, err, http.StatusNotFound)
+		return
+	}
+	logger.Info("API key revoked", "key_id", key.ID, logging.KeyClientKey, key.ClientKey)
+	writeJSON(w, key, http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}, status int) {
+	var resBuf = new(bytes.Buffer)
+	if err := json.NewEncoder(resBuf).Encode(value); err != nil {
+		ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(resBuf.Bytes())
+}
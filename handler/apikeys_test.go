@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+func TestAPIKeys_IssueAndRevoke(t *testing.T) {
+	api := newTestAPI(t)
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"clientKey":"new-client"}`, http.StatusCreated},
+		{`{"role":"admin"}`, http.StatusCreated},
+		{`{"role":"client"}`, http.StatusBadRequest},
+		{`{"clientKey":"new-client","role":"root"}`, http.StatusBadRequest},
+		{`{"clientKey":`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := api.do("admin", "POST", "/admin/keys/", test.body)
+		if w.Code != test.status {
+			t.Fatalf("expected %d issuing key %s but got %d: %s", test.status, test.body, w.Code, w.Body.String())
+		}
+	}
+
+	w := api.do("admin", "POST", "/admin/keys/", `{"clientKey":"new-client","secret":"chosen"}`)
+	assertStatus(t, w, http.StatusCreated, "POST", "/admin/keys/")
+	var key entity.APIKey
+	json.NewDecoder(w.Body).Decode(&key)
+	if key.Secret == "" || key.Secret == "chosen" || key.Role != entity.RoleClient {
+		t.Fatalf("expected a generated secret and the client role but got %+v", key)
+	}
+	api.secrets["new-client"] = key.Secret
+	assertStatus(t, api.do("new-client", "GET", "/jobs/", ""), http.StatusOK, "GET", "/jobs/")
+
+	w = api.do("admin", "GET", "/admin/keys/", "")
+	assertStatus(t, w, http.StatusOK, "GET", "/admin/keys/")
+	var keys []entity.APIKey
+	json.NewDecoder(w.Body).Decode(&keys)
+	for _, listed := range keys {
+		if listed.Secret != "" {
+			t.Fatalf("expected listed keys without secrets but got %+v", listed)
+		}
+	}
+
+	assertStatus(t, api.do("admin", "DELETE", "/admin/keys/"+key.ID, ""), http.StatusOK, "DELETE", "/admin/keys/"+key.ID)
+	assertStatus(t, api.do("new-client", "GET", "/jobs/", ""), http.StatusUnauthorized, "GET", "/jobs/")
+	assertStatus(t, api.do("admin", "DELETE", "/admin/keys/unknown", ""), http.StatusNotFound, "DELETE", "/admin/keys/unknown")
+}
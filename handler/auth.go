@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+type contextKey int
+
+const callerKey contextKey = iota
+
+// anonymousAdmin is the caller of every request when authentication is disabled
+var anonymousAdmin = entity.APIKey{Role: entity.RoleAdmin}
+
+// Authenticator identifies API callers by the API key sent in the 'Authorization: Bearer <key>'
+// or 'X-API-Key' request headers
+type Authenticator struct {
+	keys    repository.APIKeys
+	enabled bool
+}
+
+// NewAuthenticator returns an Authenticator backed by the given keys repository. When it isn't
+// enabled every request is handled as coming from an admin.
+func NewAuthenticator(keys repository.APIKeys, enabled bool) *Authenticator {
+	return &Authenticator{keys: keys, enabled: enabled}
+}
+
+// Wrap returns a handler that authenticates requests before passing them to the given handler,
+// responding 401 Unauthorized to requests without a valid API key. It must wrap the router, so
+// route variables are set in the request handed to the route handlers.
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := anonymousAdmin
+		if a.enabled {
+			key, err := a.authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				ErrorResponse(w, err, http.StatusUnauthorized)
+				return
+			}
+			caller = key
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey, caller)))
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (entity.APIKey, error) {
+	secret := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		secret = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if secret == "" {
+		return entity.APIKey{}, fmt.Errorf("missing API key")
+	}
+	return a.keys.FindBySecret(secret)
+}
+
+// Caller returns the API key that authenticated the request. Requests that didn't go through
+// an Authenticator are handled as coming from an admin.
+func Caller(r *http.Request) entity.APIKey {
+	if caller, ok := r.Context().Value(callerKey).(entity.APIKey); ok {
+		return caller
+	}
+	return anonymousAdmin
+}
+
+// canAccess returns whether the request caller is allowed to see and change the given job
+func canAccess(r *http.Request, job *entity.Job) bool {
+	caller := Caller(r)
+	return caller.IsAdmin() || caller.ClientKey == job.ClientKey
+}
+
+// requireAdmin responds 403 Forbidden and returns false if the request caller isn't an admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if caller := Caller(r); !caller.IsAdmin() {
+		ErrorResponse(w, fmt.Errorf("admin role required"), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+func TestAuth_ClientsOnlyAccessTheirJobs(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/jobs/" + id},
+		{"DELETE", "/jobs/" + id},
+		{"POST", "/jobs/" + id + "/pause"},
+		{"POST", "/jobs/" + id + "/resume"},
+		{"POST", "/jobs/" + id + "/run"},
+		{"POST", "/jobs/" + id + "/run?dryRun=true"},
+		{"GET", "/jobs/" + id + "/executions"},
+	}
+	for _, test := range tests {
+		w := api.do("other", test.method, test.path, "")
+		assertStatus(t, w, http.StatusNotFound, test.method, test.path)
+	}
+	if job, _ := api.jobs.Get(id); job.Status != entity.JobStatusPending || len(job.Executions) > 0 {
+		t.Fatalf("expected the job to be left untouched by another client but got %+v", job)
+	}
+
+	w := api.do("acme", "GET", "/jobs/"+id, "")
+	assertStatus(t, w, http.StatusOK, "GET", "/jobs/"+id)
+	w = api.do("admin", "GET", "/jobs/"+id, "")
+	assertStatus(t, w, http.StatusOK, "GET", "/jobs/"+id)
+}
+
+func TestAuth_ClientsOnlyListTheirJobs(t *testing.T) {
+	api := newTestAPI(t)
+	api.createJob(t, "acme")
+	api.createJob(t, "other")
+
+	tests := []struct {
+		caller string
+		path   string
+		count  int
+	}{
+		{"acme", "/jobs/", 1},
+		{"acme", "/jobs/?clientKey=other", 1},
+		{"other", "/jobs/", 1},
+		{"admin", "/jobs/", 2},
+		{"admin", "/jobs/?clientKey=other", 1},
+	}
+	for _, test := range tests {
+		w := api.do(test.caller, "GET", test.path, "")
+		assertStatus(t, w, http.StatusOK, "GET", test.path)
+		var jobs []entity.Job
+		if err := json.NewDecoder(w.Body).Decode(&jobs); err != nil {
+			t.Fatalf("unable to decode jobs: %v", err)
+		}
+		if len(jobs) != test.count {
+			t.Fatalf("expected %d jobs listing %s as %s but got %d", test.count, test.path, test.caller, len(jobs))
+		}
+		for _, job := range jobs {
+			if test.caller != "admin" && job.ClientKey != test.caller {
+				t.Fatalf("expected %s to only list its jobs but got one of %s", test.caller, job.ClientKey)
+			}
+		}
+	}
+}
+
+func TestAuth_ClientsOnlyChangeTheirJobsInBatches(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+
+	for _, action := range []string{"cancel", "pause"} {
+		path := "/jobs/batch/" + action
+		w := api.do("other", "POST", path, `{"ids":["`+id+`"]}`)
+		assertStatus(t, w, http.StatusOK, "POST", path)
+		var results []BatchResult
+		json.NewDecoder(w.Body).Decode(&results)
+		if len(results) != 1 || !strings.Contains(results[0].Error, "not found") {
+			t.Fatalf("expected the job of another client not to be found but got %+v", results)
+		}
+
+		// filters are scoped to the caller's jobs
+		w = api.do("other", "POST", path+"?clientKey=acme", "")
+		assertStatus(t, w, http.StatusOK, "POST", path+"?clientKey=acme")
+		json.NewDecoder(w.Body).Decode(&results)
+		if len(results) != 0 {
+			t.Fatalf("expected no job of another client to match but got %+v", results)
+		}
+	}
+	if job, _ := api.jobs.Get(id); job.Status != entity.JobStatusPending {
+		t.Fatalf("expected the job to be left pending but got %s", job.Status)
+	}
+}
+
+func TestAuth_AdminRoutesRequireAdmin(t *testing.T) {
+	api := newTestAPI(t)
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/admin/keys/", ""},
+		{"POST", "/admin/keys/", `{"clientKey":"acme"}`},
+		{"DELETE", "/admin/keys/some-id", ""},
+		{"GET", "/admin/clients/acme/limits", ""},
+		{"PUT", "/admin/clients/acme/limits", `{"maxPendingJobs":1000}`},
+		{"DELETE", "/admin/clients/acme/limits", ""},
+		{"GET", "/admin/hosts/", ""},
+		{"GET", "/admin/status", ""},
+		{"POST", "/admin/reload", ""},
+		{"GET", "/metrics", ""},
+	}
+	for _, test := range tests {
+		w := api.do("acme", test.method, test.path, test.body)
+		assertStatus(t, w, http.StatusForbidden, test.method, test.path)
+		w = api.do("", test.method, test.path, test.body)
+		assertStatus(t, w, http.StatusUnauthorized, test.method, test.path)
+	}
+}
+
+func TestAuth_RequiresValidKey(t *testing.T) {
+	api := newTestAPI(t)
+	tests := []struct {
+		header string
+		value  string
+		status int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"Authorization", "Bearer invalid", http.StatusUnauthorized},
+		{"Authorization", "Basic " + api.secrets["acme"], http.StatusUnauthorized},
+		{"Authorization", "Bearer " + api.secrets["acme"], http.StatusOK},
+		{"X-API-Key", api.secrets["acme"], http.StatusOK},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/jobs/", nil)
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+		w := api.serve(r)
+		if w.Code != test.status {
+			t.Fatalf("expected %d with header %s '%s' but got %d", test.status, test.header, test.value, w.Code)
+		}
+	}
+
+	// probes don't authenticate
+	for _, path := range []string{"/healthz", "/readyz"} {
+		assertStatus(t, api.do("", "GET", path, ""), http.StatusOK, "GET", path)
+	}
+}
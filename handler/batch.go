@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/repository"
+)
+
+const (
+	// MaxBatchSize is the maximum number of jobs in a single batch request
+	MaxBatchSize = 50000
+)
+
+// BatchResult is the outcome of a single item of a batch request
+type BatchResult struct {
+	ID       string `json:"id,omitempty"`
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchCancelRequest lists the IDs of the jobs of a batch operation
+type BatchCancelRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// CreateBatch creates the jobs from a JSON array or a NDJSON stream ('application/x-ndjson'
+// content type). The response lists the result of each job in the same order of the request.
+func (h *Jobs) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	jobs, err := ParseJobs(r, MaxBatchSize)
+	if err != nil {
+		logger.Warn("error parsing job batch", logging.KeyClientKey, Caller(r).ClientKey, logging.KeyError, err)
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	_, span := h.config.Tracer.Start(r.Context(), "job.create_batch")
+	defer span.End()
+	span.SetAttribute("batch.size", len(jobs))
+	for i := range jobs {
+		stampCaller(r, &jobs[i])
+		jobs[i].TraceParent = span.Traceparent()
+	}
+
+	newJobs, errs := h.repository.AddAll(jobs)
+	results := make([]BatchResult, len(jobs))
+	created := 0
+	var limitErr *quota.Error
+	for i := range results {
+		if errs[i] != nil {
+			if err, ok := errs[i].(*quota.Error); ok && err.Limit != quota.LimitDataSize {
+				limitErr = err
+			}
+			results[i] = BatchResult{Error: errs[i].Error()}
+			continue
+		}
+		results[i] = BatchResult{ID: newJobs[i].ID, Location: fmt.Sprintf("%s%s", h.path, newJobs[i].ID)}
+		created++
+	}
+
+	// the batch is only rejected as a whole when limits kept all of its jobs from being created
+	status := http.StatusOK
+	if created == 0 && limitErr != nil {
+		w.Header().Set("Retry-After", retryAfter(limitErr.RetryAfter))
+		status = http.StatusTooManyRequests
+	}
+	writeJSON(w, results, status)
+}
+
+// CancelBatch cancels the jobs with the IDs given in the request body or, when no IDs are given,
+// all the jobs matching the filter parameters accepted by List.
+func (h *Jobs) CancelBatch(w http.ResponseWriter, r *http.Request) {
+	ids, _, ok := h.batchIDs(w, r, "")
+	if !ok {
+		return
+	}
+	writeJSON(w, h.applyBatch(r, ids, func(id string) error {
+		_, err := h.repository.Cancel(id)
+		return err
+	}), http.StatusOK)
+}
+
+// batchIDs returns the job IDs given in the request body or, when no IDs are given, the ones of
+// the jobs matching the filter parameters, restricted to the given status if not blank, along with
+// the filter. It responds with an error and returns false when the request is invalid.
+func (h *Jobs) batchIDs(w http.ResponseWriter, r *http.Request, status string) ([]string, *repository.JobFilter, bool) {
+	var req BatchCancelRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorResponse(w, err, http.StatusBadRequest)
+			return nil, nil, false
+		}
+	}
+
+	ids := req.IDs
+	var filter *repository.JobFilter
+	if len(ids) == 0 {
+		query, err := ParseJobQuery(r, MaxPageSize)
+		if err != nil {
+			ErrorResponse(w, err, http.StatusBadRequest)
+			return nil, nil, false
+		}
+		if query.Filter == (repository.JobFilter{}) {
+			ErrorResponse(w, fmt.Errorf("a list of job IDs or at least one filter is required"), http.StatusBadRequest)
+			return nil, nil, false
+		}
+		if status != "" {
+			query.Filter.Status = status
+		}
+		scopeToCaller(r, &query.Filter)
+		if ids, err = h.listIDs(query.Filter); err != nil {
+			ErrorResponse(w, err, http.StatusInternalServerError)
+			return nil, nil, false
+		}
+		filter = &query.Filter
+	}
+	if len(ids) > MaxBatchSize {
+		ErrorResponse(w, fmt.Errorf("too many jobs in a single request, the maximum is %d", MaxBatchSize), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return ids, filter, true
+}
+
+// applyBatch applies the operation to each of the jobs the caller can access
+func (h *Jobs) applyBatch(r *http.Request, ids []string, op func(id string) error) []BatchResult {
+	results := make([]BatchResult, len(ids))
+	for i, id := range ids {
+		results[i] = BatchResult{ID: id}
+		if job, err := h.repository.Get(id); err != nil || job.ID == "" || !canAccess(r, &job) {
+			results[i].Error = fmt.Sprintf("job ID=%s not found", id)
+			continue
+		}
+		if err := op(id); err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+	return results
+}
+
+func (h *Jobs) listIDs(filter repository.JobFilter) ([]string, error) {
+	ids := make([]string, 0)
+	query := repository.JobQuery{Filter: filter, Limit: MaxPageSize}
+	for {
+		page, err := h.repository.List(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range page.Jobs {
+			ids = append(ids, job.ID)
+		}
+		if page.NextCursor == "" {
+			return ids, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+func decodeResults(t *testing.T, w *httptest.ResponseRecorder) []BatchResult {
+	var results []BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("unable to decode batch results: %v", err)
+	}
+	return results
+}
+
+func TestJobs_CreateBatch(t *testing.T) {
+	api := newTestAPI(t)
+	invalid := `{"callbackURL":"http://example.com/callback","schedule":{"format":"timestamp","value":"soon"}}`
+
+	w := api.do("acme", "POST", "/jobs/batch", "["+testJobBody("other")+","+invalid+"]")
+	assertStatus(t, w, http.StatusOK, "POST", "/jobs/batch")
+	results := decodeResults(t, w)
+	if len(results) != 2 || results[0].ID == "" || results[0].Location != "/jobs/"+results[0].ID || results[1].Error == "" {
+		t.Fatalf("expected the first job created and the second rejected but got %+v", results)
+	}
+	if job, _ := api.jobs.Get(results[0].ID); job.ClientKey != "acme" {
+		t.Fatalf("expected the job to belong to the caller but got client '%s'", job.ClientKey)
+	}
+
+	r := httptest.NewRequest("POST", "/jobs/batch", strings.NewReader(testJobBody("acme")+"\n"+testJobBody("acme")+"\n"))
+	r.Header.Set("Authorization", "Bearer "+api.secrets["acme"])
+	r.Header.Set("Content-Type", "application/x-ndjson")
+	w = api.serve(r)
+	assertStatus(t, w, http.StatusOK, "POST", "/jobs/batch")
+	if results := decodeResults(t, w); len(results) != 2 || results[0].Error != "" || results[1].Error != "" {
+		t.Fatalf("expected both NDJSON jobs created but got %+v", results)
+	}
+
+	for _, body := range []string{"", "{}", "[" + testJobBody("acme")} {
+		assertStatus(t, api.do("acme", "POST", "/jobs/batch", body), http.StatusBadRequest, "POST", "/jobs/batch")
+	}
+}
+
+func TestJobs_CreateBatchOverLimits(t *testing.T) {
+	api := newTestAPI(t)
+	assertStatus(t, api.do("admin", "PUT", "/admin/clients/acme/limits", `{"maxPendingJobs":1}`), http.StatusOK, "PUT", "/admin/clients/acme/limits")
+	api.createJob(t, "acme")
+
+	w := api.do("acme", "POST", "/jobs/batch", "["+testJobBody("acme")+","+testJobBody("acme")+"]")
+	assertStatus(t, w, http.StatusTooManyRequests, "POST", "/jobs/batch")
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected a Retry-After header")
+	}
+	if n := api.jobs.Count(repository.JobFilter{ClientKey: "acme"}); n != 1 {
+		t.Fatalf("expected no job created over the limit but got %d jobs", n)
+	}
+}
+
+func TestJobs_CancelBatch(t *testing.T) {
+	api := newTestAPI(t)
+	ids := []string{api.createJob(t, "acme"), api.createJob(t, "acme"), api.createJob(t, "other")}
+
+	w := api.do("acme", "POST", "/jobs/batch/cancel", `{"ids":["`+ids[0]+`","unknown"]}`)
+	assertStatus(t, w, http.StatusOK, "POST", "/jobs/batch/cancel")
+	if results := decodeResults(t, w); len(results) != 2 || results[0].Error != "" || results[1].Error == "" {
+		t.Fatalf("expected the first job canceled and the second not found but got %+v", results)
+	}
+
+	// admins filter on any client
+	w = api.do("admin", "POST", "/jobs/batch/cancel?clientKey=acme&status=pending", "")
+	assertStatus(t, w, http.StatusOK, "POST", "/jobs/batch/cancel")
+	if results := decodeResults(t, w); len(results) != 1 || results[0].ID != ids[1] {
+		t.Fatalf("expected the pending job of acme canceled but got %+v", results)
+	}
+	if n := api.jobs.Count(repository.JobFilter{Status: entity.JobStatusCanceled}); n != 2 {
+		t.Fatalf("expected 2 canceled jobs but got %d", n)
+	}
+
+	for _, path := range []string{"/jobs/batch/cancel", "/jobs/batch/cancel?scheduledAfter=tomorrow", "/jobs/batch/cancel?clientKey=acme&sort=priority"} {
+		assertStatus(t, api.do("acme", "POST", path, ""), http.StatusBadRequest, "POST", path)
+	}
+	assertStatus(t, api.do("acme", "POST", "/jobs/batch/cancel", `{"ids":`), http.StatusBadRequest, "POST", "/jobs/batch/cancel")
+}
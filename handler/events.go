@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marcoshack/schedula/events"
+)
+
+// keepAliveInterval is how often a comment is sent on idle event streams, so proxies don't close them
+const keepAliveInterval = 15 * time.Second
+
+// Subscriber subscribes to job events
+type Subscriber interface {
+	Subscribe(filter events.Filter, lastID uint64) *events.Subscription
+}
+
+// Events is a HTTP handler streaming job events
+type Events struct {
+	bus Subscriber
+}
+
+// NewEventsHandler ...
+func NewEventsHandler(bus Subscriber) *Events {
+	return &Events{bus: bus}
+}
+
+// Stream sends the job events as server-sent events, filtered by the 'jobId', 'clientKey',
+// 'status' and 'type' parameters. Clients only receive the events of their own jobs. A stream
+// resumes after the event in the 'Last-Event-ID' header, or the 'lastEventId' parameter, as long
+// as the events after it are still kept.
+func (h *Events) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		ErrorResponse(w, fmt.Errorf("streaming not supported"), http.StatusInternalServerError)
+		return
+	}
+	params := r.URL.Query()
+	filter := events.Filter{
+		JobID:     params.Get("jobId"),
+		ClientKey: params.Get("clientKey"),
+		Status:    params.Get("status"),
+		Type:      params.Get("type"),
+	}
+	if caller := Caller(r); !caller.IsAdmin() {
+		filter.ClientKey = caller.ClientKey
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = params.Get("lastEventId")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			ErrorResponse(w, fmt.Errorf("invalid last event ID: '%s'", lastEventID), http.StatusBadRequest)
+			return
+		}
+	}
+
+	subscription := h.bus.Subscribe(filter, lastID)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, open := <-subscription.Events():
+			if !open {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
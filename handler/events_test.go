@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcoshack/schedula/events"
+)
+
+// nextEvent reads the stream until the next event, skipping comments
+func nextEvent(t *testing.T, stream *bufio.Reader) events.Event {
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unable to read event stream: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			var e events.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+				t.Fatalf("unable to decode event: %v", err)
+			}
+			return e
+		}
+	}
+}
+
+func openStream(t *testing.T, api *testAPI, server *httptest.Server, caller string, path string, lastEventID string) *http.Response {
+	r, _ := http.NewRequest("GET", server.URL+path, nil)
+	r.Header.Set("Authorization", "Bearer "+api.secrets[caller])
+	if lastEventID != "" {
+		r.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatalf("unable to open event stream: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream but got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	return res
+}
+
+func TestEvents_StreamsTheCallerEvents(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.handler)
+	defer server.Close()
+
+	// the response headers are only sent after subscribing, so no event is missed
+	res := openStream(t, api, server, "acme", "/events?clientKey=other", "")
+	defer res.Body.Close()
+	api.createJob(t, "other")
+	id := api.createJob(t, "acme")
+
+	first := nextEvent(t, bufio.NewReader(res.Body))
+	if first.ClientKey != "acme" || first.JobID != id || first.Type != events.JobCreated {
+		t.Fatalf("expected the created event of the acme job but got %+v", first)
+	}
+
+	// reconnecting resumes after the last event received
+	api.do("acme", "DELETE", "/jobs/"+id, "")
+	resumed := openStream(t, api, server, "admin", "/events", "1")
+	defer resumed.Body.Close()
+	stream := bufio.NewReader(resumed.Body)
+	for _, expected := range []string{events.JobCreated, events.JobCanceled} {
+		if e := nextEvent(t, stream); e.JobID != id || e.Type != expected {
+			t.Fatalf("expected the %s event of the acme job but got %+v", expected, e)
+		}
+	}
+}
+
+func TestEvents_InvalidLastEventID(t *testing.T) {
+	api := newTestAPI(t)
+	assertStatus(t, api.do("acme", "GET", "/events?lastEventId=last", ""), http.StatusBadRequest, "GET", "/events")
+}
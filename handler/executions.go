@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// Executions lists the execution history of the job specified by the 'id' path parameter, newest
+// first. Executions can be filtered by 'status' and are paginated with 'limit' and the 'cursor'
+// returned in the 'Next-Cursor' response header.
+func (h *Jobs) Executions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if job, err := h.repository.Get(id); err != nil || job.ID == "" || !canAccess(r, &job) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	query := repository.ExecutionQuery{
+		JobID:  id,
+		Status: r.URL.Query().Get("status"),
+		Cursor: r.URL.Query().Get("cursor"),
+		Limit:  ParseIntParam(r, "limit", MaxPageSize),
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	page, err := h.repository.ListExecutions(query)
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if page.NextCursor != "" {
+		w.Header().Add("Next-Cursor", page.NextCursor)
+	}
+	w.Header().Add("Page-Count", strconv.Itoa(len(page.Executions)))
+	writeJSON(w, page.Executions, http.StatusOK)
+}
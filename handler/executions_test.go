@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+func TestJobs_Executions(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+	for i, status := range []string{entity.JobStatusError, entity.JobStatusSuccess, entity.JobStatusError} {
+		if _, err := api.jobs.AppendExecution(id, entity.JobExecution{Timestamp: int64(1000 + i), Status: status}); err != nil {
+			t.Fatalf("unable to append execution: %v", err)
+		}
+	}
+
+	path := "/jobs/" + id + "/executions"
+	var cursor string
+	var pages [][]entity.JobExecution
+	for i := 0; i < 2; i++ {
+		query := "?limit=2"
+		if cursor != "" {
+			query += "&cursor=" + cursor
+		}
+		w := api.do("acme", "GET", path+query, "")
+		assertStatus(t, w, http.StatusOK, "GET", path+query)
+		var page []entity.JobExecution
+		json.NewDecoder(w.Body).Decode(&page)
+		pages = append(pages, page)
+		cursor = w.Header().Get("Next-Cursor")
+	}
+	if len(pages[0]) != 2 || pages[0][0].Timestamp != 1002 || len(pages[1]) != 1 || pages[1][0].Timestamp != 1000 || cursor != "" {
+		t.Fatalf("expected the executions newest first in pages of 2 but got %+v (next cursor '%s')", pages, cursor)
+	}
+
+	w := api.do("acme", "GET", path+"?status="+entity.JobStatusSuccess, "")
+	var executions []entity.JobExecution
+	json.NewDecoder(w.Body).Decode(&executions)
+	if len(executions) != 1 || executions[0].Timestamp != 1001 {
+		t.Fatalf("expected the successful execution but got %+v", executions)
+	}
+
+	for _, query := range []string{"?cursor=invalid", "?limit=-1"} {
+		assertStatus(t, api.do("acme", "GET", path+query, ""), http.StatusBadRequest, "GET", path+query)
+	}
+	assertStatus(t, api.do("acme", "GET", "/jobs/unknown/executions", ""), http.StatusNotFound, "GET", "/jobs/unknown/executions")
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+// DefaultTickTolerance is how long the scheduler loop can go without ticking and still be ready
+const DefaultTickTolerance = 5 * time.Second
+
+// Pinger checks the connectivity to a backend
+type Pinger interface {
+	Ping() error
+}
+
+// SchedulerStatus provides the state of the scheduler loop and of its callback hosts
+type SchedulerStatus interface {
+	Status() scheduler.Status
+	Hosts() []scheduler.HostStatus
+}
+
+// HealthConfig holds Health handler configuration parameters
+type HealthConfig struct {
+	Version        string
+	RepositoryType string
+	SchedulerType  string
+	// TickTolerance is how long the scheduler loop can go without ticking and still be ready.
+	// Defaults to DefaultTickTolerance.
+	TickTolerance time.Duration
+}
+
+// Health is a HTTP handler for liveness and readiness probes and for the server status
+type Health struct {
+	repository Pinger
+	scheduler  SchedulerStatus
+	config     HealthConfig
+	started    time.Time
+	now        func() time.Time
+}
+
+// NewHealthHandler ...
+func NewHealthHandler(repository Pinger, scheduler SchedulerStatus, c HealthConfig) *Health {
+	if c.TickTolerance <= 0 {
+		c.TickTolerance = DefaultTickTolerance
+	}
+	return &Health{repository: repository, scheduler: scheduler, config: c, started: time.Now(), now: time.Now}
+}
+
+// ReadinessResponse lists the result of each readiness check, blank when it passed
+type ReadinessResponse struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// StatusResponse describes the server and its scheduler
+type StatusResponse struct {
+	Version        string                 `json:"version"`
+	StartedAt      time.Time              `json:"startedAt"`
+	Uptime         string                 `json:"uptime"`
+	RepositoryType string                 `json:"repositoryType"`
+	SchedulerType  string                 `json:"schedulerType"`
+	Scheduler      scheduler.Status       `json:"scheduler"`
+	TickLag        string                 `json:"tickLag"`
+	Hosts          []scheduler.HostStatus `json:"hosts"`
+}
+
+// Live responds 200 OK while the server is able to handle requests
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"status": "ok"}, http.StatusOK)
+}
+
+// Ready responds 200 OK when the repository can be reached and the scheduler loop ticked within
+// the tolerance, and 503 Service Unavailable otherwise
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	res := ReadinessResponse{Ready: true, Checks: map[string]string{"repository": "", "scheduler": ""}}
+	if err := h.repository.Ping(); err != nil {
+		res.Checks["repository"] = err.Error()
+	}
+	if err := h.checkScheduler(h.scheduler.Status()); err != nil {
+		res.Checks["scheduler"] = err.Error()
+	}
+
+	status := http.StatusOK
+	for _, failure := range res.Checks {
+		if failure != "" {
+			res.Ready = false
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, res, status)
+}
+
+func (h *Health) checkScheduler(status scheduler.Status) error {
+	if !status.Running {
+		return fmt.Errorf("scheduler is not running")
+	}
+	// the first tick happens one interval after the start
+	since := status.LastTick
+	if since.IsZero() {
+		since = h.started
+	}
+	if late := h.now().Sub(since); late > h.config.TickTolerance {
+		return fmt.Errorf("scheduler last ticked %v ago, over the tolerance of %v", late.Truncate(time.Millisecond), h.config.TickTolerance)
+	}
+	return nil
+}
+
+// Status describes the server, its uptime and the state of the scheduler and callback hosts. It
+// requires the admin role.
+func (h *Health) Status(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	status := h.scheduler.Status()
+	writeJSON(w, StatusResponse{
+		Version:        h.config.Version,
+		StartedAt:      h.started,
+		Uptime:         h.now().Sub(h.started).Truncate(time.Second).String(),
+		RepositoryType: h.config.RepositoryType,
+		SchedulerType:  h.config.SchedulerType,
+		Scheduler:      status,
+		TickLag:        status.TickLag.String(),
+		Hosts:          h.scheduler.Hosts(),
+	}, http.StatusOK)
+}
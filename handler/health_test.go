@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+type stubPinger struct {
+	err error
+}
+
+func (p stubPinger) Ping() error {
+	return p.err
+}
+
+func TestHealth_Ready(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	tests := []struct {
+		name   string
+		ping   error
+		status scheduler.Status
+		code   int
+	}{
+		{"ready", nil, scheduler.Status{Running: true, LastTick: now.Add(-time.Second)}, http.StatusOK},
+		{"repository down", fmt.Errorf("connection refused"), scheduler.Status{Running: true, LastTick: now}, http.StatusServiceUnavailable},
+		{"scheduler stopped", nil, scheduler.Status{LastTick: now}, http.StatusServiceUnavailable},
+		{"scheduler late", nil, scheduler.Status{Running: true, LastTick: now.Add(-10 * time.Second)}, http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		h := NewHealthHandler(stubPinger{test.ping}, &stubScheduler{status: test.status}, HealthConfig{})
+		h.now = func() time.Time { return now }
+		w := httptest.NewRecorder()
+		h.Ready(w, httptest.NewRequest("GET", "/readyz", nil))
+		if w.Code != test.code {
+			t.Fatalf("expected %d when %s but got %d: %s", test.code, test.name, w.Code, w.Body.String())
+		}
+		var res ReadinessResponse
+		json.NewDecoder(w.Body).Decode(&res)
+		if res.Ready != (test.code == http.StatusOK) {
+			t.Fatalf("expected ready %v when %s but got %+v", test.code == http.StatusOK, test.name, res)
+		}
+	}
+}
+
+func TestHealth_Status(t *testing.T) {
+	api := newTestAPI(t)
+	api.scheduler.hosts = []scheduler.HostStatus{{Host: "example.com", Workers: 2}}
+
+	w := api.do("admin", "GET", "/admin/status", "")
+	assertStatus(t, w, http.StatusOK, "GET", "/admin/status")
+	var status StatusResponse
+	json.NewDecoder(w.Body).Decode(&status)
+	if status.Version != "test" || status.RepositoryType != "in-memory" || !status.Scheduler.Running || len(status.Hosts) != 1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	assertStatus(t, api.do("", "GET", "/healthz", ""), http.StatusOK, "GET", "/healthz")
+}
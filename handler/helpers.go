@@ -3,18 +3,44 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/repository"
 )
 
+var logger = logging.Component("api")
+
 // ErrorResponse ...
 func ErrorResponse(w http.ResponseWriter, err error, status int) {
 	w.WriteHeader(status)
 	fmt.Fprintf(w, "{\"error\":\"%s\"}", err)
 }
 
+// LimitErrorResponse responds 413 Request Entity Too Large to jobs over the client data size
+// limit, and 429 Too Many Requests with a 'Retry-After' header to clients over other limits. An
+// oversized job is rejected however long the client waits, so it isn't told to retry.
+func LimitErrorResponse(w http.ResponseWriter, err *quota.Error) {
+	if err.Limit == quota.LimitDataSize {
+		ErrorResponse(w, err, http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("Retry-After", retryAfter(err.RetryAfter))
+	ErrorResponse(w, err, http.StatusTooManyRequests)
+}
+
+// retryAfter formats the given duration as a 'Retry-After' header value, in whole seconds
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
 // ParseJob ...
 func ParseJob(r *http.Request) (entity.Job, error) {
 	var job entity.Job
@@ -22,6 +48,38 @@ func ParseJob(r *http.Request) (entity.Job, error) {
 	return job, dec.Decode(&job)
 }
 
+// ParseJobs decodes a list of jobs from the request body, either as a JSON array or, when the
+// request content type is 'application/x-ndjson', as a stream of newline delimited JSON objects.
+func ParseJobs(r *http.Request, maxJobs int) ([]entity.Job, error) {
+	dec := json.NewDecoder(r.Body)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-ndjson") {
+		var jobs []entity.Job
+		if err := dec.Decode(&jobs); err != nil {
+			return nil, err
+		}
+		if len(jobs) > maxJobs {
+			return nil, fmt.Errorf("too many jobs in a single request, the maximum is %d", maxJobs)
+		}
+		return jobs, nil
+	}
+
+	jobs := make([]entity.Job, 0)
+	for {
+		var job entity.Job
+		err := dec.Decode(&job)
+		if err == io.EOF {
+			return jobs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid job at line %d: %v", len(jobs)+1, err)
+		}
+		if len(jobs) == maxJobs {
+			return nil, fmt.Errorf("too many jobs in a single request, the maximum is %d", maxJobs)
+		}
+		jobs = append(jobs, job)
+	}
+}
+
 // ParseIntParam ...
 func ParseIntParam(r *http.Request, name string, defaultValue int) int {
 	res := defaultValue
@@ -33,3 +91,55 @@ func ParseIntParam(r *http.Request, name string, defaultValue int) int {
 	}
 	return res
 }
+
+// ParseInt64Param returns the value of the given query parameter as an int64, or zero if it's absent
+func ParseInt64Param(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	res, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for parameter '%s': '%s'", name, value)
+	}
+	return res, nil
+}
+
+// ParseJobQuery builds a repository query from the request filter, sort and pagination parameters
+func ParseJobQuery(r *http.Request, maxPageSize int) (repository.JobQuery, error) {
+	params := r.URL.Query()
+	query := repository.JobQuery{
+		Filter: repository.JobFilter{
+			Status:       params.Get("status"),
+			ClientKey:    params.Get("clientKey"),
+			CallbackHost: params.Get("callbackHost"),
+		},
+		SortBy:    params.Get("sort"),
+		SortOrder: params.Get("order"),
+		Cursor:    params.Get("cursor"),
+		Skip:      ParseIntParam(r, "skip", 0),
+		Limit:     ParseIntParam(r, "limit", maxPageSize),
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+
+	ranges := []struct {
+		name  string
+		value *int64
+	}{
+		{"scheduledAfter", &query.Filter.ScheduledAfter},
+		{"scheduledBefore", &query.Filter.ScheduledBefore},
+		{"createdAfter", &query.Filter.CreatedAfter},
+		{"createdBefore", &query.Filter.CreatedBefore},
+	}
+	for _, p := range ranges {
+		value, err := ParseInt64Param(r, p.name)
+		if err != nil {
+			return query, err
+		}
+		*p.value = value
+	}
+
+	return query, query.Validate()
+}
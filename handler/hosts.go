@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+// HostsLister provides the status of the callback hosts
+type HostsLister interface {
+	Hosts() []scheduler.HostStatus
+}
+
+// Hosts is a HTTP handler to inspect the callback hosts. All its actions require the admin role.
+type Hosts struct {
+	hosts HostsLister
+}
+
+// NewHostsHandler ...
+func NewHostsHandler(hosts HostsLister) *Hosts {
+	return &Hosts{hosts: hosts}
+}
+
+// List the status of every callback host, including its circuit breaker state
+func (h *Hosts) List(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, h.hosts.Hosts(), http.StatusOK)
+}
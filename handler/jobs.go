@@ -2,14 +2,19 @@ package handler
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/marcoshack/schedula/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/quota"
 	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
 )
 
 const (
@@ -21,11 +26,26 @@ const (
 type Jobs struct {
 	path       string
 	repository repository.Jobs
+	config     JobsConfig
+}
+
+// JobsConfig holds Jobs handler configuration parameters
+type JobsConfig struct {
+	// IdempotencyWindow is how long an idempotency key is kept after the job creation. Zero disables idempotency keys.
+	IdempotencyWindow time.Duration
+	// IdempotencyPerClient scopes idempotency keys by the job ClientKey
+	IdempotencyPerClient bool
+	// Tracer traces the job creation, whose trace context is stored on the job to link its
+	// callbacks to it. Nil disables tracing.
+	Tracer *tracing.Tracer
+	// Pauses keeps the clients and hosts paused by PauseBatch, holding their jobs created later.
+	// Nil only pauses the jobs existing at the time.
+	Pauses repository.Pauses
 }
 
 // NewJobsHandler ...
-func NewJobsHandler(path string, repo repository.Jobs) *Jobs {
-	return &Jobs{repository: repo, path: path}
+func NewJobsHandler(path string, repo repository.Jobs, c JobsConfig) *Jobs {
+	return &Jobs{repository: repo, path: path, config: c}
 }
 
 func (h *Jobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -38,49 +58,117 @@ func (h *Jobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// List all jobs in JSON format
+// List jobs in JSON format. Jobs can be filtered by 'status', 'clientKey', 'callbackHost' and the
+// 'scheduledAfter', 'scheduledBefore', 'createdAfter' and 'createdBefore' timestamps, and sorted
+// with 'sort' (createdAt or nextRun) and 'order' (asc or desc). When there are more jobs to list
+// the 'Next-Cursor' response header holds the value of the 'cursor' parameter for the next page.
 func (h *Jobs) List(w http.ResponseWriter, r *http.Request) {
-	skip := ParseIntParam(r, "skip", 0)
-	limit := ParseIntParam(r, "limit", MaxPageSize)
+	query, err := ParseJobQuery(r, MaxPageSize)
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	scopeToCaller(r, &query.Filter)
 
-	jobs, err := h.repository.List(skip, limit)
+	page, err := h.repository.List(query)
 	if err != nil {
 		ErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
 
 	var resBuf = new(bytes.Buffer)
-	encErr := json.NewEncoder(resBuf).Encode(jobs)
+	encErr := json.NewEncoder(resBuf).Encode(page.Jobs)
 	if encErr != nil {
 		ErrorResponse(w, encErr, http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Add("Page-Count", strconv.Itoa(len(jobs)))
-	w.Header().Add("Total-Count", strconv.Itoa(h.repository.Count()))
+	if page.NextCursor != "" {
+		w.Header().Add("Next-Cursor", page.NextCursor)
+	}
+	w.Header().Add("Page-Count", strconv.Itoa(len(page.Jobs)))
+	w.Header().Add("Total-Count", strconv.Itoa(h.repository.Count(query.Filter)))
 	w.Write(resBuf.Bytes())
 }
 
-// Create a job from a JSON representation
+// Create a job from a JSON representation. Requests with an 'Idempotency-Key' header can be safely
+// retried: a repeated request with the same key and job returns the Location of the job created by
+// the first one, and a repeated key with a different job is rejected with 409 Conflict.
+//
+// A job with a 'uniqueKey' conflicting with a pending job of the same client is rejected with
+// 409 Conflict, unless its 'onConflict' policy is 'replace', which cancels the pending job, or
+// 'keep-earliest', which responds 200 OK with the Location of the pending job if it runs first.
 func (h *Jobs) Create(w http.ResponseWriter, r *http.Request) {
 	job, err := ParseJob(r)
 	if err != nil {
-		log.Printf("jobs: error parsing job: %s", err)
+		logger.Warn("error parsing job", logging.KeyClientKey, Caller(r).ClientKey, logging.KeyError, err)
 		ErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
+	stampCaller(r, &job)
+
+	// the fingerprint is taken before the trace context is stamped, since every request has its own
+	key := r.Header.Get("Idempotency-Key")
+	if key != "" && h.config.IdempotencyWindow > 0 {
+		if job.Idempotency, err = h.idempotency(key, job); err != nil {
+			ErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	_, span := h.config.Tracer.Start(r.Context(), "job.create")
+	defer span.End()
+	job.TraceParent = span.Traceparent()
 
 	newJob, err := h.repository.Add(job)
+	if err == repository.ErrIdempotencyKeyExists {
+		if newJob.Idempotency.Fingerprint != job.Idempotency.Fingerprint || !canAccess(r, &newJob) {
+			ErrorResponse(w, fmt.Errorf("idempotency key '%s' was already used by a different job", key), http.StatusConflict)
+			return
+		}
+		w.Header().Add("Idempotent-Replayed", "true")
+		err = nil
+	}
+	if err == repository.ErrUniqueKeyExists {
+		w.Header().Add("Location", fmt.Sprintf("%s%s", h.path, newJob.ID))
+		if job.OnConflict == entity.JobConflictKeepEarliest {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		ErrorResponse(w, err, http.StatusConflict)
+		return
+	}
+	if limitErr, ok := err.(*quota.Error); ok {
+		LimitErrorResponse(w, limitErr)
+		return
+	}
 	if err != nil {
-		log.Printf("jobs: error scheduling job: %s", err)
+		span.RecordError(err)
+		logger.Warn("error scheduling job", logging.KeyClientKey, job.ClientKey, logging.KeyError, err)
 		ErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
+	span.SetAttribute("job.id", newJob.ID)
+	logger.Info("job created", logging.KeyJobID, newJob.ID, logging.KeyClientKey, newJob.ClientKey, "next_run", newJob.NextRun)
 	w.Header().Add("Location", fmt.Sprintf("%s%s", h.path, newJob.ID))
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (h *Jobs) idempotency(key string, job entity.Job) (*entity.JobIdempotency, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	if h.config.IdempotencyPerClient {
+		key = fmt.Sprintf("%s/%s", job.ClientKey, key)
+	}
+	return &entity.JobIdempotency{
+		Key:         key,
+		Fingerprint: fmt.Sprintf("%x", sha256.Sum256(body)),
+		ExpiresAt:   time.Now().Add(h.config.IdempotencyWindow).Unix(),
+	}, nil
+}
+
 // Find retrieves the job specified by the 'id' path parameter
 func (h *Jobs) Find(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
@@ -91,7 +179,7 @@ func (h *Jobs) Find(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if job.ID == "" {
+	if job.ID == "" || !canAccess(r, &job) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -108,8 +196,26 @@ func (h *Jobs) Find(w http.ResponseWriter, r *http.Request) {
 // Delete remove the given Job ID
 func (h *Jobs) Delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if job, err := h.repository.Get(id); err != nil || job.ID == "" || !canAccess(r, &job) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 	_, err := h.repository.Cancel(id)
 	if err != nil {
 		ErrorResponse(w, err, http.StatusInternalServerError)
 	}
 }
+
+// stampCaller sets the job ClientKey to the one of the request caller, unless it's an admin
+func stampCaller(r *http.Request, job *entity.Job) {
+	if caller := Caller(r); !caller.IsAdmin() {
+		job.ClientKey = caller.ClientKey
+	}
+}
+
+// scopeToCaller restricts the filter to the jobs of the request caller, unless it's an admin
+func scopeToCaller(r *http.Request, filter *repository.JobFilter) {
+	if caller := Caller(r); !caller.IsAdmin() {
+		filter.ClientKey = caller.ClientKey
+	}
+}
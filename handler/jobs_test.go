@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
+)
+
+func testJobBody(clientKey string) string {
+	return fmt.Sprintf(`{"clientKey":"%s","callbackURL":"http://example.com/callback","schedule":{"format":"timestamp","value":"%d"}}`,
+		clientKey, time.Now().Add(time.Hour).Unix())
+}
+
+func TestJobs_CreateIdempotentRetryWithTracer(t *testing.T) {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	tracer := tracing.NewTracer(&tracing.InMemoryExporter{})
+	h := NewJobsHandler("/jobs/", repo, JobsConfig{IdempotencyWindow: time.Hour, Tracer: tracer})
+
+	body := testJobBody("acme")
+	var locations []string
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest("POST", "/jobs/", strings.NewReader(body))
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+		h.Create(w, r)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201 Created on attempt %d but got %d: %s", i+1, w.Code, w.Body.String())
+		}
+		if replayed := w.Header().Get("Idempotent-Replayed"); (i == 1) != (replayed == "true") {
+			t.Fatalf("expected Idempotent-Replayed only on the retry but got '%s' on attempt %d", replayed, i+1)
+		}
+		locations = append(locations, w.Header().Get("Location"))
+	}
+	if locations[0] != locations[1] {
+		t.Fatalf("expected the retry to return the same job but got %v", locations)
+	}
+	if n := repo.Count(repository.JobFilter{}); n != 1 {
+		t.Fatalf("expected a single job but got %d", n)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+)
+
+// LimitsStore keeps the job creation limits of each client
+type LimitsStore interface {
+	Limits(clientKey string) entity.ClientLimits
+	SetLimits(clientKey string, limits entity.ClientLimits)
+	ResetLimits(clientKey string)
+}
+
+// Limits is a HTTP handler to manage the limits of the client specified by the 'clientKey'
+// path parameter. All its actions require the admin role.
+type Limits struct {
+	store LimitsStore
+}
+
+// NewLimitsHandler ...
+func NewLimitsHandler(store LimitsStore) *Limits {
+	return &Limits{store: store}
+}
+
+// Find retrieves the limits in effect for the client
+func (h *Limits) Find(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, h.store.Limits(mux.Vars(r)["clientKey"]), http.StatusOK)
+}
+
+// Update replaces the client limits with the ones in the JSON request body
+func (h *Limits) Update(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var limits entity.ClientLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := limits.Validate(); err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	clientKey := mux.Vars(r)["clientKey"]
+	h.store.SetLimits(clientKey, limits)
+	logger.Info("client limits set", logging.KeyClientKey, clientKey, "limits", limits)
+	writeJSON(w, limits, http.StatusOK)
+}
+
+// Delete makes the client subject to the default limits
+func (h *Limits) Delete(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	clientKey := mux.Vars(r)["clientKey"]
+	h.store.ResetLimits(clientKey)
+	logger.Info("client limits reset to defaults", logging.KeyClientKey, clientKey)
+	writeJSON(w, h.store.Limits(clientKey), http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/repository"
+)
+
+func TestLimits_UpdateRejectsNegativeValues(t *testing.T) {
+	repo, _ := repository.New("in-memory")
+	store := quota.NewJobs(repo, entity.ClientLimits{MaxPendingJobs: 10})
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/clients/{clientKey}/limits", NewLimitsHandler(store).Update).Methods("PUT")
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"maxPendingJobs":-1}`, http.StatusBadRequest},
+		{`{"createRate":-0.5,"createBurst":1}`, http.StatusBadRequest},
+		{`{"createRate":1,"createBurst":-1}`, http.StatusBadRequest},
+		{`{"maxDataSize":-1}`, http.StatusBadRequest},
+		{`{"maxPendingJobs":5,"createRate":1,"createBurst":2}`, http.StatusOK},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/clients/acme/limits", strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Fatalf("expected %d updating limits to %s but got %d: %s", test.code, test.body, w.Code, w.Body.String())
+		}
+		if test.code != http.StatusOK && store.Limits("acme").MaxPendingJobs != 10 {
+			t.Fatalf("expected invalid limits %s not to be set but got %+v", test.body, store.Limits("acme"))
+		}
+	}
+}
+
+func TestLimits_FindAndReset(t *testing.T) {
+	api := newTestAPI(t)
+	path := "/admin/clients/acme/limits"
+	assertStatus(t, api.do("admin", "PUT", path, `{"maxPendingJobs":5}`), http.StatusOK, "PUT", path)
+
+	tests := []struct {
+		method  string
+		pending int
+	}{
+		{"GET", 5},
+		{"DELETE", 0},
+		{"GET", 0},
+	}
+	for _, test := range tests {
+		w := api.do("admin", test.method, path, "")
+		assertStatus(t, w, http.StatusOK, test.method, path)
+		var limits entity.ClientLimits
+		json.NewDecoder(w.Body).Decode(&limits)
+		if limits.MaxPendingJobs != test.pending {
+			t.Fatalf("expected %d max pending jobs from %s but got %+v", test.pending, test.method, limits)
+		}
+	}
+	assertStatus(t, api.do("admin", "PUT", path, `{"maxPendingJobs":`), http.StatusBadRequest, "PUT", path)
+}
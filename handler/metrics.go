@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// Metrics is a HTTP handler exposing the metrics to Prometheus. Scraping requires the admin role,
+// as the metrics include the callback hosts of every client.
+type Metrics struct {
+	metrics http.Handler
+}
+
+// NewMetricsHandler ...
+func NewMetricsHandler(metrics http.Handler) *Metrics {
+	return &Metrics{metrics: metrics}
+}
+
+// Scrape writes the current metrics in the Prometheus text format
+func (h *Metrics) Scrape(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	h.metrics.ServeHTTP(w, r)
+}
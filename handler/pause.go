@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// Pause holds the job specified by the 'id' path parameter until it's resumed, without
+// canceling it
+func (h *Jobs) Pause(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if job, err := h.repository.Get(id); err != nil || job.ID == "" || !canAccess(r, &job) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	job, err := h.repository.Pause(id, time.Now())
+	if err != nil {
+		ErrorResponse(w, err, http.StatusConflict)
+		return
+	}
+	writeJSON(w, job, http.StatusOK)
+}
+
+// Resume makes the paused job specified by the 'id' path parameter pending again. The 'missed'
+// parameter sets what to do if its run was missed while paused: 'run-once' (default), 'run-all'
+// or 'skip'.
+func (h *Jobs) Resume(w http.ResponseWriter, r *http.Request) {
+	missed, err := parseMissedPolicy(r)
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if job, err := h.repository.Get(id); err != nil || job.ID == "" || !canAccess(r, &job) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	job, err := h.repository.Resume(id, missed, time.Now())
+	if err != nil {
+		ErrorResponse(w, err, http.StatusConflict)
+		return
+	}
+	writeJSON(w, job, http.StatusOK)
+}
+
+// PauseBatch pauses the jobs with the IDs given in the request body or, when no IDs are given,
+// all the pending jobs matching the filter parameters accepted by List. When the only filters are
+// 'clientKey' and 'callbackHost' the client or host stays paused, so the jobs created after it are
+// held too once due, until it's resumed with ResumeBatch.
+func (h *Jobs) PauseBatch(w http.ResponseWriter, r *http.Request) {
+	ids, filter, ok := h.batchIDs(w, r, entity.JobStatusPending)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if pause, ok := h.pauseOf(filter); ok {
+		pause.PausedAt = now.Unix()
+		if _, err := h.config.Pauses.Add(pause); err != nil {
+			ErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		logger.Info("pause added", logging.KeyClientKey, pause.ClientKey, logging.KeyHost, pause.CallbackHost)
+	}
+	writeJSON(w, h.applyBatch(r, ids, func(id string) error {
+		_, err := h.repository.Pause(id, now)
+		return err
+	}), http.StatusOK)
+}
+
+// ResumeBatch resumes the jobs with the IDs given in the request body or, when no IDs are given,
+// all the paused jobs matching the filter parameters accepted by List, lifting the pause of the
+// client or host given by the 'clientKey' and 'callbackHost' filters. The 'missed' parameter is
+// the same accepted by Resume.
+func (h *Jobs) ResumeBatch(w http.ResponseWriter, r *http.Request) {
+	missed, err := parseMissedPolicy(r)
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	ids, filter, ok := h.batchIDs(w, r, entity.JobStatusPaused)
+	if !ok {
+		return
+	}
+	if pause, ok := h.pauseOf(filter); ok {
+		removed, err := h.config.Pauses.Remove(pause.ClientKey, pause.CallbackHost)
+		if err != nil {
+			ErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		if removed {
+			logger.Info("pause removed", logging.KeyClientKey, pause.ClientKey, logging.KeyHost, pause.CallbackHost)
+		}
+	}
+	now := time.Now()
+	writeJSON(w, h.applyBatch(r, ids, func(id string) error {
+		_, err := h.repository.Resume(id, missed, now)
+		return err
+	}), http.StatusOK)
+}
+
+// pauseOf returns the pause of the client and host of a batch filter, false if the filter has
+// other conditions or pauses aren't kept
+func (h *Jobs) pauseOf(filter *repository.JobFilter) (entity.Pause, bool) {
+	if filter == nil || h.config.Pauses == nil {
+		return entity.Pause{}, false
+	}
+	target := repository.JobFilter{ClientKey: filter.ClientKey, CallbackHost: filter.CallbackHost}
+	conditions := *filter
+	conditions.Status = ""
+	if conditions != target {
+		return entity.Pause{}, false
+	}
+	return entity.Pause{ClientKey: filter.ClientKey, CallbackHost: filter.CallbackHost}, true
+}
+
+// Pauses lists the clients and hosts paused, restricted to the ones of the caller's client unless
+// it's an admin
+func (h *Jobs) Pauses(w http.ResponseWriter, r *http.Request) {
+	pauses := make([]entity.Pause, 0)
+	if h.config.Pauses != nil {
+		all, err := h.config.Pauses.List()
+		if err != nil {
+			ErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		caller := Caller(r)
+		for _, pause := range all {
+			if caller.IsAdmin() || pause.ClientKey == caller.ClientKey {
+				pauses = append(pauses, pause)
+			}
+		}
+	}
+	writeJSON(w, pauses, http.StatusOK)
+}
+
+func parseMissedPolicy(r *http.Request) (string, error) {
+	missed := r.URL.Query().Get("missed")
+	if missed == "" {
+		return entity.JobMissedRunOnce, nil
+	}
+	if !entity.IsValidMissedPolicy(missed) {
+		return "", fmt.Errorf("invalid missed runs policy: '%s'", missed)
+	}
+	return missed, nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+func TestJobs_PauseBatchKeepsHostPaused(t *testing.T) {
+	repo, _ := repository.New("in-memory")
+	pauses, _ := repository.NewPauses("in-memory")
+	h := NewJobsHandler("/jobs/", repo, JobsConfig{Pauses: pauses})
+	h.Create(httptest.NewRecorder(), httptest.NewRequest("POST", "/jobs/", strings.NewReader(testJobBody("acme"))))
+
+	w := httptest.NewRecorder()
+	h.PauseBatch(w, httptest.NewRequest("POST", "/jobs/batch/pause?callbackHost=example.com", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK but got %d: %s", w.Code, w.Body.String())
+	}
+	if n := repo.Count(repository.JobFilter{Status: entity.JobStatusPaused}); n != 1 {
+		t.Fatalf("expected the existing job to be paused but got %d paused", n)
+	}
+	if list, _ := pauses.List(); len(list) != 1 || list[0].CallbackHost != "example.com" || list[0].PausedAt == 0 {
+		t.Fatalf("expected the host to stay paused but got %+v", list)
+	}
+
+	// other filters only pause the existing jobs
+	h.PauseBatch(httptest.NewRecorder(), httptest.NewRequest("POST", "/jobs/batch/pause?clientKey=acme&scheduledAfter=1", nil))
+	if list, _ := pauses.List(); len(list) != 1 {
+		t.Fatalf("expected no pause for a filter with other conditions but got %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	h.ResumeBatch(w, httptest.NewRequest("POST", "/jobs/batch/resume?callbackHost=example.com&missed=skip", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK but got %d: %s", w.Code, w.Body.String())
+	}
+	if list, _ := pauses.List(); len(list) != 0 {
+		t.Fatalf("expected the host pause to be lifted but got %+v", list)
+	}
+	if n := repo.Count(repository.JobFilter{Status: entity.JobStatusPending}); n != 1 {
+		t.Fatalf("expected the job to be resumed but got %d pending", n)
+	}
+}
+
+func TestJobs_PauseAndResume(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+
+	tests := []struct {
+		path   string
+		status int
+		after  string
+	}{
+		{"/jobs/" + id + "/resume", http.StatusConflict, entity.JobStatusPending},
+		{"/jobs/" + id + "/pause", http.StatusOK, entity.JobStatusPaused},
+		{"/jobs/" + id + "/pause", http.StatusOK, entity.JobStatusPaused},
+		{"/jobs/" + id + "/resume?missed=later", http.StatusBadRequest, entity.JobStatusPaused},
+		{"/jobs/" + id + "/resume?missed=skip", http.StatusOK, entity.JobStatusPending},
+		{"/jobs/unknown/pause", http.StatusNotFound, entity.JobStatusPending},
+	}
+	for _, test := range tests {
+		assertStatus(t, api.do("acme", "POST", test.path, ""), test.status, "POST", test.path)
+		if job, _ := api.jobs.Get(id); job.Status != test.after {
+			t.Fatalf("expected the job %s after POST %s but got %s", test.after, test.path, job.Status)
+		}
+	}
+}
+
+func TestJobs_PausesOnlyListsTheCallerPauses(t *testing.T) {
+	api := newTestAPI(t)
+	for _, caller := range []string{"acme", "other"} {
+		assertStatus(t, api.do(caller, "POST", "/jobs/batch/pause?callbackHost=example.com", ""), http.StatusOK, "POST", "/jobs/batch/pause")
+	}
+
+	tests := []struct {
+		caller  string
+		clients []string
+	}{
+		{"acme", []string{"acme"}},
+		{"other", []string{"other"}},
+		{"admin", []string{"acme", "other"}},
+	}
+	for _, test := range tests {
+		w := api.do(test.caller, "GET", "/pauses", "")
+		assertStatus(t, w, http.StatusOK, "GET", "/pauses")
+		var pauses []entity.Pause
+		json.NewDecoder(w.Body).Decode(&pauses)
+		if len(pauses) != len(test.clients) {
+			t.Fatalf("expected %s to list the pauses of %v but got %+v", test.caller, test.clients, pauses)
+		}
+		for _, pause := range pauses {
+			if test.caller != "admin" && pause.ClientKey != test.caller {
+				t.Fatalf("expected %s to only list its pauses but got %+v", test.caller, pause)
+			}
+		}
+	}
+}
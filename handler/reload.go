@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// Reloader reloads the runtime configuration
+type Reloader interface {
+	Reload() (ReloadResult, error)
+}
+
+// ReloadResult lists the settings changed by a reload, by whether they were applied or only take
+// effect after a restart
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// Reload is a HTTP handler reloading the configuration
+type Reload struct {
+	reloader Reloader
+}
+
+// NewReloadHandler ...
+func NewReloadHandler(reloader Reloader) *Reload {
+	return &Reload{reloader: reloader}
+}
+
+// Reload reads the configuration file and environment variables again and applies the runtime
+// settings. An invalid configuration is rejected, keeping the current one.
+func (h *Reload) Reload(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	result, err := h.reloader.Reload()
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, result, http.StatusOK)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+// JobRunner executes job callbacks on demand
+type JobRunner interface {
+	Run(job entity.Job) (entity.JobExecution, error)
+}
+
+// Run is a HTTP handler to execute job callbacks on demand
+type Run struct {
+	repository repository.Jobs
+	runner     JobRunner
+}
+
+// DryRunResponse is the callback request a job run would send
+type DryRunResponse struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// NewRunHandler ...
+func NewRunHandler(repo repository.Jobs, runner JobRunner) *Run {
+	return &Run{repository: repo, runner: runner}
+}
+
+// Run executes the callback of the job specified by the 'id' path parameter right away, leaving
+// its schedule untouched, and responds with the execution, also added to the job executions.
+// With 'dryRun=true' the callback request is rendered in the response instead of sent.
+func (h *Run) Run(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	job, err := h.repository.Get(id)
+	if err != nil || job.ID == "" || !canAccess(r, &job) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun")); dryRun {
+		h.dryRun(w, job)
+		return
+	}
+
+	execution, err := h.runner.Run(job)
+	if breakerErr, ok := err.(*scheduler.BreakerOpenError); ok {
+		w.Header().Set("Retry-After", retryAfter(breakerErr.RetryAt.Sub(time.Now())))
+		ErrorResponse(w, err, http.StatusServiceUnavailable)
+		return
+	}
+	if err == scheduler.ErrHostQueueFull || err == scheduler.ErrSchedulerStopped {
+		ErrorResponse(w, err, http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, execution, http.StatusOK)
+}
+
+func (h *Run) dryRun(w http.ResponseWriter, job entity.Job) {
+	req, err := callback.NewRequest(scheduler.ManualRun(job, "", time.Now()))
+	if err != nil {
+		ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, DryRunResponse{Method: req.Method, URL: req.URL.String(), Header: req.Header, Body: string(body)}, http.StatusOK)
+}
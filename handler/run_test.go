@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+func TestRun_RunsJobOnDemand(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+	path := "/jobs/" + id + "/run"
+
+	w := api.do("acme", "POST", path, "")
+	assertStatus(t, w, http.StatusOK, "POST", path)
+	var execution entity.JobExecution
+	json.NewDecoder(w.Body).Decode(&execution)
+	if execution.Trigger != entity.JobTriggerManual {
+		t.Fatalf("expected a manual execution but got %+v", execution)
+	}
+
+	w = api.do("acme", "POST", path+"?dryRun=true", "")
+	assertStatus(t, w, http.StatusOK, "POST", path+"?dryRun=true")
+	var dryRun DryRunResponse
+	json.NewDecoder(w.Body).Decode(&dryRun)
+	if dryRun.Method != "POST" || dryRun.URL != "http://example.com/callback" {
+		t.Fatalf("expected the rendered callback request but got %+v", dryRun)
+	}
+
+	assertStatus(t, api.do("acme", "POST", "/jobs/unknown/run", ""), http.StatusNotFound, "POST", "/jobs/unknown/run")
+}
+
+func TestRun_RunnerErrors(t *testing.T) {
+	api := newTestAPI(t)
+	id := api.createJob(t, "acme")
+	path := "/jobs/" + id + "/run"
+
+	tests := []struct {
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{scheduler.ErrHostQueueFull, http.StatusServiceUnavailable, ""},
+		{scheduler.ErrSchedulerStopped, http.StatusServiceUnavailable, ""},
+		{&scheduler.BreakerOpenError{Host: "example.com", RetryAt: time.Now().Add(30 * time.Second)}, http.StatusServiceUnavailable, "30"},
+		{scheduler.ErrHostNotAllowed, http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		api.scheduler.runErr = test.err
+		w := api.do("acme", "POST", path, "")
+		assertStatus(t, w, test.status, "POST", path)
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != test.retryAfter {
+			t.Fatalf("expected Retry-After '%s' for error '%v' but got '%s'", test.retryAfter, test.err, retryAfter)
+		}
+	}
+}
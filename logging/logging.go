@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Keys of the fields shared by the log records of every package, so records of the same job or
+// host can be indexed together
+const (
+	KeyComponent = "component"
+	KeyJobID     = "job_id"
+	KeyClientKey = "client_key"
+	KeyHost      = "host"
+	KeyAttempt   = "attempt"
+	KeyStatus    = "status"
+	KeyLatency   = "latency"
+	KeyError     = "error"
+)
+
+const (
+	// FormatText writes records as key=value pairs
+	FormatText = "text"
+
+	// FormatJSON writes records as JSON objects, one per line
+	FormatJSON = "json"
+)
+
+// Config holds the logging configuration parameters
+type Config struct {
+	// Level is the minimum level of the records written: debug, info, warn or error
+	Level string
+	// Format is FormatText or FormatJSON
+	Format string
+}
+
+// level is shared by every handler created by Setup, so it can be changed while running
+var level = new(slog.LevelVar)
+
+// root is the handler every component logger writes through. Loggers are usually created before
+// Setup runs, so they look it up on each record.
+var root atomic.Value
+
+func init() {
+	root.Store(handlerBox{slog.NewTextHandler(log.Writer(), &slog.HandlerOptions{Level: level})})
+}
+
+// handlerBox keeps the stored handlers of the same concrete type, as atomic.Value requires
+type handlerBox struct {
+	slog.Handler
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return l, fmt.Errorf("invalid log level: '%s'", name)
+	}
+	return l, nil
+}
+
+// Setup validates the configuration and makes every logger, including the default slog and log
+// ones, write to w with the configured level and format
+func Setup(w io.Writer, c Config) error {
+	l, err := ParseLevel(c.Level)
+	if err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(c.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("invalid log format: '%s'", c.Format)
+	}
+	level.Set(l)
+	root.Store(handlerBox{handler})
+	slog.SetDefault(slog.New(&switchHandler{}))
+	return nil
+}
+
+// SetLevel changes the minimum level of the records written
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Level returns the minimum level of the records written
+func Level() slog.Level {
+	return level.Level()
+}
+
+// Component returns a logger whose records have the component field
+func Component(name string) *slog.Logger {
+	return slog.New(&switchHandler{}).With(KeyComponent, name)
+}
+
+// switchHandler writes records through the current root handler, replaying the attributes and
+// groups added to it
+type switchHandler struct {
+	with []func(slog.Handler) slog.Handler
+}
+
+func (h *switchHandler) current() slog.Handler {
+	handler := root.Load().(handlerBox).Handler
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	return handler
+}
+
+func (h *switchHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h *switchHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.current().Handle(ctx, r)
+}
+
+func (h *switchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *switchHandler) WithGroup(name string) slog.Handler {
+	return h.extend(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *switchHandler) extend(with func(slog.Handler) slog.Handler) slog.Handler {
+	extended := make([]func(slog.Handler) slog.Handler, len(h.with), len(h.with)+1)
+	copy(extended, h.with)
+	return &switchHandler{with: append(extended, with)}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func Test_Setup_JSON(t *testing.T) {
+	logger := Component("scheduler").With(KeyJobID, "job-1")
+	var buf bytes.Buffer
+	if err := Setup(&buf, Config{Level: "info", Format: FormatJSON}); err != nil {
+		t.Fatalf("unable to set up logging: %v", err)
+	}
+
+	logger.Debug("hidden")
+	logger.Info("callback executed", KeyStatus, "success")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON record but got '%s': %v", buf.String(), err)
+	}
+	if record["msg"] != "callback executed" || record[KeyComponent] != "scheduler" || record[KeyJobID] != "job-1" || record[KeyStatus] != "success" {
+		t.Fatalf("unexpected record fields: %v", record)
+	}
+}
+
+func Test_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, Config{Level: "warn"}); err != nil {
+		t.Fatalf("unable to set up logging: %v", err)
+	}
+	logger := Component("api")
+
+	logger.Info("hidden")
+	SetLevel(slog.LevelDebug)
+	logger.Debug("shown")
+
+	if output := buf.String(); strings.Contains(output, "hidden") || !strings.Contains(output, "msg=shown component=api") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func Test_Setup_Invalid(t *testing.T) {
+	if err := Setup(&bytes.Buffer{}, Config{Level: "verbose"}); err == nil {
+		t.Fatalf("expected an error setting an invalid level")
+	}
+	if err := Setup(&bytes.Buffer{}, Config{Level: "info", Format: "xml"}); err == nil {
+		t.Fatalf("expected an error setting an invalid format")
+	}
+}
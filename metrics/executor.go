@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/entity"
+)
+
+// Executor is a callback.Executor decorator recording the latency of the callbacks per host
+type Executor struct {
+	callback.Executor
+	recorder Recorder
+}
+
+// NewExecutor returns an Executor decorator over the given executor
+func NewExecutor(e callback.Executor, r Recorder) *Executor {
+	return &Executor{Executor: e, recorder: r}
+}
+
+// Execute ...
+func (e *Executor) Execute(job entity.Job) error {
+	start := time.Now()
+	err := e.Executor.Execute(job)
+	host := ""
+	if u, parseErr := url.Parse(job.CallbackURL); parseErr == nil {
+		host = u.Host
+	}
+	e.recorder.CallbackLatency(host, time.Since(start))
+	return err
+}
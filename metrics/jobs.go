@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// Jobs is a repository.Jobs decorator recording the latency of every operation, along with the
+// jobs created, canceled and executed through it
+type Jobs struct {
+	repository.Jobs
+	recorder Recorder
+}
+
+// NewJobs returns a Jobs decorator over the given repository
+func NewJobs(r repository.Jobs, recorder Recorder) *Jobs {
+	return &Jobs{Jobs: r, recorder: recorder}
+}
+
+// observe records the latency of the operation started at the given time
+func (m *Jobs) observe(operation string, start time.Time) {
+	m.recorder.RepositoryLatency(operation, time.Since(start))
+}
+
+// Add ...
+func (m *Jobs) Add(job entity.Job) (entity.Job, error) {
+	defer m.observe("add", time.Now())
+	job, err := m.Jobs.Add(job)
+	if err == nil {
+		m.recorder.JobsCreated(1)
+	}
+	return job, err
+}
+
+// AddAll ...
+func (m *Jobs) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	defer m.observe("add_all", time.Now())
+	res, errs := m.Jobs.AddAll(jobs)
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+		}
+	}
+	m.recorder.JobsCreated(created)
+	return res, errs
+}
+
+// Get ...
+func (m *Jobs) Get(id string) (entity.Job, error) {
+	defer m.observe("get", time.Now())
+	return m.Jobs.Get(id)
+}
+
+// List ...
+func (m *Jobs) List(query repository.JobQuery) (repository.JobPage, error) {
+	defer m.observe("list", time.Now())
+	return m.Jobs.List(query)
+}
+
+// Remove ...
+func (m *Jobs) Remove(jobID string) (entity.Job, error) {
+	defer m.observe("remove", time.Now())
+	return m.Jobs.Remove(jobID)
+}
+
+// Cancel ...
+func (m *Jobs) Cancel(jobID string) (entity.Job, error) {
+	defer m.observe("cancel", time.Now())
+	job, err := m.Jobs.Cancel(jobID)
+	if err == nil {
+		m.recorder.JobCanceled()
+	}
+	return job, err
+}
+
+// Reschedule ...
+func (m *Jobs) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	defer m.observe("reschedule", time.Now())
+	return m.Jobs.Reschedule(jobID, timestamp)
+}
+
+// Pause ...
+func (m *Jobs) Pause(jobID string, now time.Time) (entity.Job, error) {
+	defer m.observe("pause", time.Now())
+	return m.Jobs.Pause(jobID, now)
+}
+
+// Resume ...
+func (m *Jobs) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	defer m.observe("resume", time.Now())
+	return m.Jobs.Resume(jobID, missed, now)
+}
+
+// AddExecution ...
+func (m *Jobs) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
+	defer m.observe("add_execution", time.Now())
+	job, err := m.Jobs.AddExecution(jobID, date, status, message)
+	if err == nil {
+		m.recorder.JobExecuted(status)
+	}
+	return job, err
+}
+
+// AppendExecution ...
+func (m *Jobs) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	defer m.observe("append_execution", time.Now())
+	job, err := m.Jobs.AppendExecution(jobID, execution)
+	if err == nil {
+		m.recorder.JobExecuted(execution.Status)
+	}
+	return job, err
+}
+
+// ListExecutions ...
+func (m *Jobs) ListExecutions(query repository.ExecutionQuery) (repository.ExecutionPage, error) {
+	defer m.observe("list_executions", time.Now())
+	return m.Jobs.ListExecutions(query)
+}
+
+// Count ...
+func (m *Jobs) Count(filter repository.JobFilter) int {
+	defer m.observe("count", time.Now())
+	return m.Jobs.Count(filter)
+}
+
+// ListBySchedule ...
+func (m *Jobs) ListBySchedule(timestamp int64) ([]entity.Job, error) {
+	defer m.observe("list_by_schedule", time.Now())
+	return m.Jobs.ListBySchedule(timestamp)
+}
+
+// ClaimDue ...
+func (m *Jobs) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	defer m.observe("claim_due", time.Now())
+	return m.Jobs.ClaimDue(timestamp, owner, now, ttl)
+}
+
+// Heartbeat ...
+func (m *Jobs) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	defer m.observe("heartbeat", time.Now())
+	return m.Jobs.Heartbeat(jobID, owner, now, ttl)
+}
+
+// Complete ...
+func (m *Jobs) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	defer m.observe("complete", time.Now())
+	job, err := m.Jobs.Complete(jobID, owner, date, status, message)
+	if err == nil {
+		m.recorder.JobExecuted(status)
+	}
+	return job, err
+}
+
+// Ping ...
+func (m *Jobs) Ping() error {
+	defer m.observe("ping", time.Now())
+	return m.Jobs.Ping()
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+type RecorderMock struct {
+	sync.Mutex
+	created    int
+	canceled   int
+	executed   map[string]int
+	operations map[string]int
+}
+
+func (m *RecorderMock) JobsCreated(count int) {
+	m.Lock()
+	defer m.Unlock()
+	m.created += count
+}
+
+func (m *RecorderMock) JobCanceled() {
+	m.Lock()
+	defer m.Unlock()
+	m.canceled++
+}
+
+func (m *RecorderMock) JobExecuted(status string) {
+	m.Lock()
+	defer m.Unlock()
+	m.executed[status]++
+}
+
+func (m *RecorderMock) CallbackLatency(host string, latency time.Duration) {}
+
+func (m *RecorderMock) TickLag(lag time.Duration) {}
+
+func (m *RecorderMock) RepositoryLatency(operation string, latency time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.operations[operation]++
+}
+
+func newJobs(t *testing.T) (*Jobs, *RecorderMock) {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	m := &RecorderMock{executed: make(map[string]int), operations: make(map[string]int)}
+	return NewJobs(repo, m), m
+}
+
+func aJob() entity.Job {
+	return entity.Job{
+		CallbackURL: "http://example.com/callback",
+		Schedule:    entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: "1234567890"},
+	}
+}
+
+func Test_Jobs_RecordsJobEvents(t *testing.T) {
+	jobs, m := newJobs(t)
+	job, err := jobs.Add(aJob())
+	if err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+	invalid := aJob()
+	invalid.Schedule.Value = "invalid"
+	jobs.AddAll([]entity.Job{aJob(), invalid})
+	if _, err := jobs.Cancel(job.ID); err != nil {
+		t.Fatalf("unable to cancel job: %v", err)
+	}
+	if _, err := jobs.Cancel("unknown"); err == nil {
+		t.Fatalf("expected an error canceling an unknown job")
+	}
+	jobs.AddExecution(job.ID, time.Now(), entity.JobStatusError, "failed")
+
+	if m.created != 2 || m.canceled != 1 || m.executed[entity.JobStatusError] != 1 {
+		t.Fatalf("expected 2 jobs created, 1 canceled and 1 failed execution but got %+v", m)
+	}
+	if m.operations["add"] != 1 || m.operations["add_all"] != 1 || m.operations["cancel"] != 2 {
+		t.Fatalf("expected every operation latency to be recorded but got %v", m.operations)
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import "time"
+
+// Recorder records the events Schedula is instrumented for. Components take a Recorder rather
+// than using a global registry, so they can be tested with Nop or a mock.
+type Recorder interface {
+	JobsCreated(count int)
+	JobCanceled()
+	JobExecuted(status string)
+	// CallbackLatency records how long the callback request to the host took
+	CallbackLatency(host string, latency time.Duration)
+	// TickLag records how late a job callback fired after the time it was scheduled to
+	TickLag(lag time.Duration)
+	// RepositoryLatency records how long a repository operation took
+	RepositoryLatency(operation string, latency time.Duration)
+}
+
+// Nop is a Recorder that discards every event
+type Nop struct{}
+
+// JobsCreated ...
+func (Nop) JobsCreated(count int) {}
+
+// JobCanceled ...
+func (Nop) JobCanceled() {}
+
+// JobExecuted ...
+func (Nop) JobExecuted(status string) {}
+
+// CallbackLatency ...
+func (Nop) CallbackLatency(host string, latency time.Duration) {}
+
+// TickLag ...
+func (Nop) TickLag(lag time.Duration) {}
+
+// RepositoryLatency ...
+func (Nop) RepositoryLatency(operation string, latency time.Duration) {}
+
+// Prometheus is a Recorder keeping the events as metrics of a Registry
+type Prometheus struct {
+	*Registry
+	created    *Counter
+	canceled   *Counter
+	executed   *Counter
+	callbacks  *Histogram
+	tickLag    *Histogram
+	repository *Histogram
+}
+
+// NewPrometheus returns a Prometheus recorder with its metrics registered in a new Registry.
+// Gauges of the current state, like queue depths, are registered by the caller.
+func NewPrometheus() *Prometheus {
+	r := NewRegistry()
+	return &Prometheus{
+		Registry:   r,
+		created:    r.Counter("schedula_jobs_created_total", "Number of jobs created."),
+		canceled:   r.Counter("schedula_jobs_canceled_total", "Number of jobs canceled."),
+		executed:   r.Counter("schedula_jobs_executed_total", "Number of job executions, by status.", "status"),
+		callbacks:  r.Histogram("schedula_callback_duration_seconds", "Latency of the callback requests, by host.", DefaultBuckets, "host"),
+		tickLag:    r.Histogram("schedula_scheduler_tick_lag_seconds", "Delay between the time a job was scheduled to and the time its callback fired.", DefaultBuckets),
+		repository: r.Histogram("schedula_repository_operation_duration_seconds", "Latency of the repository operations, by operation.", DefaultBuckets, "operation"),
+	}
+}
+
+// JobsCreated ...
+func (p *Prometheus) JobsCreated(count int) {
+	p.created.Add(float64(count))
+}
+
+// JobCanceled ...
+func (p *Prometheus) JobCanceled() {
+	p.canceled.Inc()
+}
+
+// JobExecuted ...
+func (p *Prometheus) JobExecuted(status string) {
+	p.executed.Inc(status)
+}
+
+// CallbackLatency ...
+func (p *Prometheus) CallbackLatency(host string, latency time.Duration) {
+	p.callbacks.Observe(latency.Seconds(), host)
+}
+
+// TickLag ...
+func (p *Prometheus) TickLag(lag time.Duration) {
+	p.tickLag.Observe(lag.Seconds())
+}
+
+// RepositoryLatency ...
+func (p *Prometheus) RepositoryLatency(operation string, latency time.Duration) {
+	p.repository.Observe(latency.Seconds(), operation)
+}
@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the histogram upper bounds, in seconds, used for latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds a set of metrics and writes them in the Prometheus text exposition format.
+// Metrics are written in the order they were registered.
+type Registry struct {
+	sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Sample is a gauge value along with its label values
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter registers a counter with the given label names. Counters without labels start at zero.
+func (r *Registry) Counter(name string, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]*counterValue)}
+	if len(labels) == 0 {
+		c.values[""] = &counterValue{}
+	}
+	r.register(c)
+	return c
+}
+
+// Histogram registers a histogram with the given bucket upper bounds and label names
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	r.register(h)
+	return h
+}
+
+// Gauge registers a gauge whose samples are collected by the given function every time the
+// registry is written
+func (r *Registry) Gauge(name string, help string, labels []string, collect func() []Sample) {
+	r.register(&gauge{desc: desc{name: name, help: help, labels: labels}, collect: collect})
+}
+
+func (r *Registry) register(m metric) {
+	r.Lock()
+	defer r.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes every metric to w
+func (r *Registry) Write(w io.Writer) error {
+	r.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	return buf.Flush()
+}
+
+// ServeHTTP writes the metrics in response to a Prometheus scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// desc describes a metric and its label names
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.Replace(d.help, "\n", " ", -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, kind)
+}
+
+// key joins the label values, checking they match the label names
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sample writes a metric line with the label pairs of the given values, plus the extra pair
+func (d *desc) sample(w *bufio.Writer, suffix string, values []string, extra string, value float64) {
+	w.WriteString(d.name + suffix)
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", d.labels[i], escape(v)))
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// Counter is a monotonically increasing metric
+type Counter struct {
+	desc
+	sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Add increases the counter of the given label values
+func (c *Counter) Add(delta float64, labels ...string) {
+	key := c.key(labels)
+	c.Lock()
+	defer c.Unlock()
+	v, exists := c.values[key]
+	if !exists {
+		v = &counterValue{labels: labels}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+// Inc increases the counter of the given label values by one
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.header(w, "counter")
+	c.Lock()
+	defer c.Unlock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		v := c.values[key]
+		c.sample(w, "", v.labels, "", v.value)
+	}
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	desc
+	sync.Mutex
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds the value to the histogram of the given label values
+func (h *Histogram) Observe(value float64, labels ...string) {
+	key := h.key(labels)
+	h.Lock()
+	defer h.Unlock()
+	v, exists := h.values[key]
+	if !exists {
+		v = &histogramValue{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.header(w, "histogram")
+	h.Lock()
+	defer h.Unlock()
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		v := h.values[key]
+		for i, bound := range h.buckets {
+			h.sample(w, "_bucket", v.labels, fmt.Sprintf("le=\"%s\"", formatFloat(bound)), float64(v.counts[i]))
+		}
+		h.sample(w, "_bucket", v.labels, "le=\"+Inf\"", float64(v.count))
+		h.sample(w, "_sum", v.labels, "", v.sum)
+		h.sample(w, "_count", v.labels, "", float64(v.count))
+	}
+}
+
+// gauge is a metric whose current samples are collected when written
+type gauge struct {
+	desc
+	collect func() []Sample
+}
+
+func (g *gauge) write(w *bufio.Writer) {
+	g.header(w, "gauge")
+	samples := g.collect()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	for _, s := range samples {
+		g.key(s.Labels)
+		g.sample(w, "", s.Labels, "", s.Value)
+	}
+}
+
+func escape(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
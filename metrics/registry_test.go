@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, r *Registry) string {
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unable to write metrics: %v", err)
+	}
+	return buf.String()
+}
+
+func assertLines(t *testing.T, output string, lines ...string) {
+	for _, line := range lines {
+		if !strings.Contains(output, line+"\n") {
+			t.Fatalf("expected line '%s' in output:\n%s", line, output)
+		}
+	}
+}
+
+func Test_Registry_Counter(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("jobs_total", "Number of jobs.", "status")
+	c.Inc("success")
+	c.Add(2, "error")
+	c.Inc("success")
+
+	assertLines(t, write(t, r),
+		"# HELP jobs_total Number of jobs.",
+		"# TYPE jobs_total counter",
+		`jobs_total{status="error"} 2`,
+		`jobs_total{status="success"} 2`,
+	)
+}
+
+func Test_Registry_Histogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "host")
+	h.Observe(0.05, "example.com")
+	h.Observe(0.5, "example.com")
+	h.Observe(2, "example.com")
+
+	assertLines(t, write(t, r),
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{host="example.com",le="0.1"} 1`,
+		`latency_seconds_bucket{host="example.com",le="1"} 2`,
+		`latency_seconds_bucket{host="example.com",le="+Inf"} 3`,
+		`latency_seconds_sum{host="example.com"} 2.55`,
+		`latency_seconds_count{host="example.com"} 3`,
+	)
+}
+
+func Test_Registry_Gauge(t *testing.T) {
+	r := NewRegistry()
+	depth := 3.0
+	r.Gauge("queue_depth", "Queue depth.", []string{"host"}, func() []Sample {
+		return []Sample{{Labels: []string{`b"\`}, Value: depth}, {Labels: []string{"a"}, Value: 1}}
+	})
+	r.Gauge("pending", "Pending jobs.", nil, func() []Sample {
+		return []Sample{{Value: 7}}
+	})
+
+	output := write(t, r)
+	assertLines(t, output, "# TYPE queue_depth gauge", `queue_depth{host="b\"\\"} 3`, "pending 7")
+	if strings.Index(output, `host="a"`) > strings.Index(output, `host="b`) {
+		t.Fatalf("expected samples sorted by labels but got:\n%s", output)
+	}
+
+	depth = 0
+	assertLines(t, write(t, r), `queue_depth{host="b\"\\"} 0`)
+}
+
+func Test_Prometheus_Recorder(t *testing.T) {
+	p := NewPrometheus()
+	p.JobsCreated(2)
+	p.JobCanceled()
+	p.JobExecuted("success")
+	p.CallbackLatency("example.com", 20*time.Millisecond)
+	p.TickLag(time.Second)
+	p.RepositoryLatency("add", time.Millisecond)
+
+	assertLines(t, write(t, p.Registry),
+		"schedula_jobs_created_total 2",
+		"schedula_jobs_canceled_total 1",
+		`schedula_jobs_executed_total{status="success"} 1`,
+		`schedula_callback_duration_seconds_count{host="example.com"} 1`,
+		"schedula_scheduler_tick_lag_seconds_sum 1",
+		`schedula_repository_operation_duration_seconds_count{operation="add"} 1`,
+	)
+}
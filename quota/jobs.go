@@ -0,0 +1,318 @@
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/ratelimit"
+	"github.com/marcoshack/schedula/repository"
+)
+
+const (
+	// LimitPendingJobs ...
+	LimitPendingJobs = "maxPendingJobs"
+
+	// LimitCreateRate ...
+	LimitCreateRate = "createRate"
+
+	// LimitDataSize ...
+	LimitDataSize = "maxDataSize"
+
+	// pendingRetryAfter is suggested to clients over their pending jobs limit, as there's no
+	// telling when their jobs will run
+	pendingRetryAfter = 60 * time.Second
+)
+
+// Error is returned when a job isn't added because its client exceeded one of its limits
+type Error struct {
+	ClientKey  string
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client '%s' exceeded its %s limit", e.ClientKey, e.Limit)
+}
+
+// Jobs is a repository.Jobs decorator that enforces per client limits on job creation. Clients
+// without limits of their own are subject to the default ones.
+type Jobs struct {
+	repository.Jobs
+	sync.Mutex
+	defaults entity.ClientLimits
+	limits   map[string]entity.ClientLimits
+	clients  map[string]*client
+	now      func() time.Time
+}
+
+// client serializes the job creation of a single client, so its pending jobs count holds
+type client struct {
+	sync.Mutex
+	bucket *ratelimit.Bucket
+	// pending is the number of unfinished jobs of the client, counted in the repository only once
+	// and then kept as jobs are added and finish
+	pending int
+	counted bool
+}
+
+// NewJobs returns a Jobs decorator over the given repository
+func NewJobs(r repository.Jobs, defaults entity.ClientLimits) *Jobs {
+	return &Jobs{
+		Jobs:     r,
+		defaults: defaults,
+		limits:   make(map[string]entity.ClientLimits),
+		clients:  make(map[string]*client),
+		now:      time.Now,
+	}
+}
+
+// Limits returns the limits in effect for the given client
+func (q *Jobs) Limits(clientKey string) entity.ClientLimits {
+	q.Lock()
+	defer q.Unlock()
+	if limits, exists := q.limits[clientKey]; exists {
+		return limits
+	}
+	return q.defaults
+}
+
+// SetDefaults replaces the default limits, applied right away to the clients without limits of
+// their own
+func (q *Jobs) SetDefaults(defaults entity.ClientLimits) {
+	q.Lock()
+	defer q.Unlock()
+	q.defaults = defaults
+}
+
+// SetLimits replaces the default limits of the given client
+func (q *Jobs) SetLimits(clientKey string, limits entity.ClientLimits) {
+	q.Lock()
+	defer q.Unlock()
+	q.limits[clientKey] = limits
+}
+
+// ResetLimits makes the given client subject to the default limits again
+func (q *Jobs) ResetLimits(clientKey string) {
+	q.Lock()
+	defer q.Unlock()
+	delete(q.limits, clientKey)
+}
+
+// Add adds the job if its client is within its limits, otherwise returns an *Error
+func (q *Jobs) Add(job entity.Job) (entity.Job, error) {
+	c := q.client(job.ClientKey)
+	c.Lock()
+	defer c.Unlock()
+
+	if errs := q.check(c, job.ClientKey, []entity.Job{job}); errs[0] != nil {
+		return entity.Job{}, errs[0]
+	}
+	added, err := q.Jobs.Add(job)
+	if err != nil {
+		c.refund(1)
+	} else if added.ReplacedID == "" {
+		c.addPending(1)
+	}
+	return added, err
+}
+
+// AddAll adds the jobs of each client as long as the client is within its limits. The remaining
+// jobs aren't added and have an *Error set.
+func (q *Jobs) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	res := make([]entity.Job, len(jobs))
+	errs := make([]error, len(jobs))
+
+	byClient := make(map[string][]entity.Job)
+	indexes := make(map[string][]int)
+	for i, job := range jobs {
+		byClient[job.ClientKey] = append(byClient[job.ClientKey], job)
+		indexes[job.ClientKey] = append(indexes[job.ClientKey], i)
+	}
+
+	// lock clients in a fixed order, so concurrent batches don't deadlock
+	clientKeys := make([]string, 0, len(byClient))
+	for clientKey := range byClient {
+		clientKeys = append(clientKeys, clientKey)
+	}
+	sort.Strings(clientKeys)
+
+	allowed := make([]entity.Job, 0, len(jobs))
+	allowedIndexes := make([]int, 0, len(jobs))
+	for _, clientKey := range clientKeys {
+		c := q.client(clientKey)
+		c.Lock()
+		defer c.Unlock()
+		for i, err := range q.check(c, clientKey, byClient[clientKey]) {
+			if err != nil {
+				errs[indexes[clientKey][i]] = err
+				continue
+			}
+			allowed = append(allowed, byClient[clientKey][i])
+			allowedIndexes = append(allowedIndexes, indexes[clientKey][i])
+		}
+	}
+
+	added, addErrs := q.Jobs.AddAll(allowed)
+	for i, index := range allowedIndexes {
+		res[index], errs[index] = added[i], addErrs[i]
+		if c := q.client(allowed[i].ClientKey); addErrs[i] != nil {
+			c.refund(1)
+		} else if added[i].ReplacedID == "" {
+			c.addPending(1)
+		}
+	}
+	return res, errs
+}
+
+// refund returns the creation tokens taken for jobs that weren't created, like the ones rejected
+// by the repository or replayed with an idempotency key. The client must be locked.
+func (c *client) refund(n int) {
+	if c.bucket != nil {
+		c.bucket.Return(n)
+	}
+}
+
+// addPending adds n to the pending jobs count, once it's been counted. The client must be locked.
+func (c *client) addPending(n int) {
+	if c.counted {
+		c.pending += n
+	}
+}
+
+// Cancel ...
+func (q *Jobs) Cancel(jobID string) (entity.Job, error) {
+	return q.track(jobID, false, func() (entity.Job, error) { return q.Jobs.Cancel(jobID) })
+}
+
+// Remove ...
+func (q *Jobs) Remove(jobID string) (entity.Job, error) {
+	return q.track(jobID, true, func() (entity.Job, error) { return q.Jobs.Remove(jobID) })
+}
+
+// Resume ...
+func (q *Jobs) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	return q.track(jobID, false, func() (entity.Job, error) { return q.Jobs.Resume(jobID, missed, now) })
+}
+
+// AddExecution ...
+func (q *Jobs) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
+	return q.track(jobID, false, func() (entity.Job, error) { return q.Jobs.AddExecution(jobID, date, status, message) })
+}
+
+// Complete ...
+func (q *Jobs) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	return q.track(jobID, false, func() (entity.Job, error) { return q.Jobs.Complete(jobID, owner, date, status, message) })
+}
+
+// track applies the operation to the job with its client locked, taking the job out of the
+// client's pending jobs count when the operation finishes or removes it
+func (q *Jobs) track(jobID string, removes bool, op func() (entity.Job, error)) (entity.Job, error) {
+	job, err := q.Jobs.Get(jobID)
+	if err != nil || job.ID == "" {
+		return op()
+	}
+	c := q.client(job.ClientKey)
+	c.Lock()
+	defer c.Unlock()
+
+	before, _ := q.Jobs.Get(jobID)
+	after, err := op()
+	if err == nil && unfinished.Match(&before) && (removes || !unfinished.Match(&after)) {
+		c.addPending(-1)
+	}
+	return after, err
+}
+
+// unfinished matches the jobs counted as pending
+var unfinished = repository.JobFilter{Unfinished: true}
+
+func (q *Jobs) client(clientKey string) *client {
+	q.Lock()
+	defer q.Unlock()
+	c := q.clients[clientKey]
+	if c == nil {
+		c = &client{}
+		q.clients[clientKey] = c
+	}
+	return c
+}
+
+// check returns the limit errors of each job of the given client, which must be locked. Jobs
+// that pass the check are expected to be added, or their creation tokens refunded. Jobs sharing
+// the unique key of an unfinished job can only replace it or be rejected, so they aren't subject
+// to the pending jobs limit.
+func (q *Jobs) check(c *client, clientKey string, jobs []entity.Job) []error {
+	errs := make([]error, len(jobs))
+	limits := q.Limits(clientKey)
+
+	replacing := make([]bool, len(jobs))
+	candidates, replacements := 0, 0
+	for i := range jobs {
+		if limits.MaxDataSize > 0 && jobs[i].DataSize() > limits.MaxDataSize {
+			errs[i] = &Error{ClientKey: clientKey, Limit: LimitDataSize}
+			continue
+		}
+		if limits.MaxPendingJobs > 0 && q.sharesUniqueKey(jobs[i]) {
+			replacing[i] = true
+			replacements++
+			continue
+		}
+		candidates++
+	}
+
+	room := candidates
+	if limits.MaxPendingJobs > 0 {
+		if free := limits.MaxPendingJobs - q.pendingJobs(c, clientKey); room > free {
+			room = free
+		}
+		if room < 0 {
+			room = 0
+		}
+	}
+	tokens := room + replacements
+	var wait time.Duration
+	if tokens > 0 && limits.CreateRate > 0 {
+		if c.bucket == nil || c.bucket.Rate() != limits.CreateRate || c.bucket.Burst() != limits.CreateBurst {
+			c.bucket = ratelimit.NewBucket(limits.CreateRate, limits.CreateBurst)
+		}
+		tokens, wait = c.bucket.Take(tokens, q.now())
+	}
+
+	for i := range jobs {
+		if errs[i] != nil {
+			continue
+		}
+		if !replacing[i] && room <= 0 {
+			errs[i] = &Error{ClientKey: clientKey, Limit: LimitPendingJobs, RetryAfter: pendingRetryAfter}
+			continue
+		}
+		if tokens <= 0 {
+			errs[i] = &Error{ClientKey: clientKey, Limit: LimitCreateRate, RetryAfter: wait}
+			continue
+		}
+		tokens--
+		if !replacing[i] {
+			room--
+		}
+	}
+	return errs
+}
+
+// pendingJobs returns the number of unfinished jobs of the given client, which must be locked.
+// The repository is only scanned the first time.
+func (q *Jobs) pendingJobs(c *client, clientKey string) int {
+	if !c.counted {
+		c.pending = q.Jobs.Count(repository.JobFilter{ClientKey: clientKey, Unfinished: true})
+		c.counted = true
+	}
+	return c.pending
+}
+
+// sharesUniqueKey returns whether the job has the unique key of an unfinished job of its client
+func (q *Jobs) sharesUniqueKey(job entity.Job) bool {
+	return job.UniqueKey != "" &&
+		q.Jobs.Count(repository.JobFilter{ClientKey: job.ClientKey, UniqueKey: job.UniqueKey, Unfinished: true}) > 0
+}
@@ -0,0 +1,162 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+func newJobs(t *testing.T, defaults entity.ClientLimits) *Jobs {
+	repo, err := repository.New("in-memory")
+	if err != nil {
+		t.Fatalf("unable to initialize repository: %v", err)
+	}
+	q := NewJobs(repo, defaults)
+	now := time.Unix(1438948984, 0)
+	q.now = func() time.Time { return now }
+	return q
+}
+
+func aJob(clientKey string) entity.Job {
+	return entity.Job{
+		ClientKey: clientKey,
+		Schedule:  entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: "1234567890"},
+	}
+}
+
+func assertLimitError(t *testing.T, err error, limit string) {
+	limitErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected a limit error but got '%v'", err)
+	}
+	if limitErr.Limit != limit {
+		t.Fatalf("expected %s limit to be exceeded but got %s", limit, limitErr.Limit)
+	}
+}
+
+func Test_Jobs_MaxPendingJobs(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{MaxPendingJobs: 2})
+	for i := 0; i < 2; i++ {
+		if _, err := q.Add(aJob("foo")); err != nil {
+			t.Fatalf("unable to add job: %v", err)
+		}
+	}
+	_, err := q.Add(aJob("foo"))
+	assertLimitError(t, err, LimitPendingJobs)
+
+	if _, err := q.Add(aJob("bar")); err != nil {
+		t.Fatalf("expected other clients not to be limited but got: %v", err)
+	}
+}
+
+func Test_Jobs_CreateRate(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{CreateRate: 1, CreateBurst: 2})
+	_, errs := q.AddAll([]entity.Job{aJob("foo"), aJob("foo"), aJob("foo")})
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("expected burst of 2 jobs to be added but got: %v", errs)
+	}
+	assertLimitError(t, errs[2], LimitCreateRate)
+	if errs[2].(*Error).RetryAfter != time.Second {
+		t.Fatalf("expected to retry after 1s but got %v", errs[2].(*Error).RetryAfter)
+	}
+}
+
+func Test_Jobs_MaxDataSize(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{})
+	q.SetLimits("foo", entity.ClientLimits{MaxDataSize: 8})
+	job := aJob("foo")
+	job.Data = map[string]string{"key": "too long"}
+	_, err := q.Add(job)
+	assertLimitError(t, err, LimitDataSize)
+
+	q.ResetLimits("foo")
+	if _, err := q.Add(job); err != nil {
+		t.Fatalf("expected default limits to allow job but got: %v", err)
+	}
+}
+
+func Test_Jobs_CreateRateRefundsJobsNotCreated(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{CreateRate: 0.001, CreateBurst: 2})
+	job := aJob("foo")
+	job.Idempotency = &entity.JobIdempotency{Key: "foo/key", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	if _, err := q.Add(job); err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+	if _, err := q.Add(job); err != repository.ErrIdempotencyKeyExists {
+		t.Fatalf("expected idempotent replay but got %v", err)
+	}
+
+	invalid := aJob("foo")
+	invalid.Schedule.Value = "invalid"
+	if _, errs := q.AddAll([]entity.Job{invalid}); errs[0] == nil {
+		t.Fatalf("expected error adding job with invalid schedule")
+	}
+	if _, err := q.Add(aJob("foo")); err != nil {
+		t.Fatalf("expected the tokens of the jobs not created to be refunded but got: %v", err)
+	}
+	_, err := q.Add(aJob("foo"))
+	assertLimitError(t, err, LimitCreateRate)
+}
+
+// countingJobs counts the Count calls, which scan the repository
+type countingJobs struct {
+	repository.Jobs
+	counts int
+}
+
+func (r *countingJobs) Count(filter repository.JobFilter) int {
+	r.counts++
+	return r.Jobs.Count(filter)
+}
+
+func Test_Jobs_MaxPendingJobsCountedOnce(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{MaxPendingJobs: 2})
+	repo := &countingJobs{Jobs: q.Jobs}
+	q.Jobs = repo
+
+	first, _ := q.Add(aJob("foo"))
+	q.Add(aJob("foo"))
+	if _, err := q.Cancel(first.ID); err != nil {
+		t.Fatalf("unable to cancel job: %v", err)
+	}
+	if _, err := q.Add(aJob("foo")); err != nil {
+		t.Fatalf("expected the canceled job to free its slot but got: %v", err)
+	}
+
+	// claimed jobs finish when completed
+	claimed, _ := q.ClaimDue(1234567890, "node", q.now(), time.Minute)
+	if len(claimed) != 2 {
+		t.Fatalf("expected to claim the 2 pending jobs but got %d", len(claimed))
+	}
+	q.Complete(claimed[0].ID, "node", q.now(), entity.JobStatusSuccess, "")
+	q.Remove(claimed[1].ID)
+	for i := 0; i < 2; i++ {
+		if _, err := q.Add(aJob("foo")); err != nil {
+			t.Fatalf("expected the completed and removed jobs to free their slots but got: %v", err)
+		}
+	}
+	_, err := q.Add(aJob("foo"))
+	assertLimitError(t, err, LimitPendingJobs)
+	if repo.counts != 1 {
+		t.Fatalf("expected the pending jobs to be counted once but got %d counts", repo.counts)
+	}
+}
+
+func Test_Jobs_MaxPendingJobsIgnoresReplacements(t *testing.T) {
+	q := newJobs(t, entity.ClientLimits{MaxPendingJobs: 1})
+	job := aJob("foo")
+	job.UniqueKey = "reminder"
+	job.OnConflict = entity.JobConflictReplace
+	for i := 0; i < 3; i++ {
+		if _, err := q.Add(job); err != nil {
+			t.Fatalf("expected replacement %d not to count as a pending job but got: %v", i, err)
+		}
+	}
+	_, errs := q.AddAll([]entity.Job{job, aJob("foo")})
+	if errs[0] != nil {
+		t.Fatalf("expected replacement in batch not to count as a pending job but got: %v", errs[0])
+	}
+	assertLimitError(t, errs[1], LimitPendingJobs)
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket rate limiter. It holds up to 'burst' tokens and is refilled at 'rate'
+// tokens per second. A rate of zero disables the limit.
+type Bucket struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket returns a full Bucket
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// Rate returns the bucket refill rate in tokens per second
+func (b *Bucket) Rate() float64 {
+	return b.rate
+}
+
+// Burst returns the bucket capacity
+func (b *Bucket) Burst() int {
+	return int(b.burst)
+}
+
+// Take removes up to n tokens from the bucket at the given time, returning how many were taken
+// and, if less than n, how long to wait until the next token is available.
+func (b *Bucket) Take(n int, now time.Time) (int, time.Duration) {
+	if b.rate <= 0 {
+		return n, 0
+	}
+	b.Lock()
+	defer b.Unlock()
+	b.refill(now)
+
+	taken := int(math.Min(float64(n), math.Floor(b.tokens)))
+	if taken < 0 {
+		taken = 0
+	}
+	b.tokens -= float64(taken)
+	if taken == n {
+		return taken, 0
+	}
+	return taken, b.wait(1)
+}
+
+// Return puts back n tokens taken but not used, up to the bucket capacity
+func (b *Bucket) Return(n int) {
+	if b.rate <= 0 {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+float64(n))
+}
+
+// Reserve takes a token from the bucket at the given time even if it isn't available yet,
+// returning how long the caller has to wait before using it.
+func (b *Bucket) Reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.Lock()
+	defer b.Unlock()
+	b.refill(now)
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return b.wait(0)
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+// wait returns how long it takes for the bucket to hold the given number of tokens
+func (b *Bucket) wait(tokens float64) time.Duration {
+	missing := tokens - b.tokens
+	return time.Duration(math.Ceil(missing / b.rate * float64(time.Second)))
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBucket(2, 3)
+	if taken, wait := b.Take(5, now); taken != 3 || wait != 500*time.Millisecond {
+		t.Fatalf("expected to take 3 tokens and wait 500ms but got %d and %v", taken, wait)
+	}
+	if taken, _ := b.Take(1, now.Add(250*time.Millisecond)); taken != 0 {
+		t.Fatalf("expected no tokens available after 250ms but took %d", taken)
+	}
+	if taken, wait := b.Take(1, now.Add(500*time.Millisecond)); taken != 1 || wait != 0 {
+		t.Fatalf("expected to take 1 token after 500ms but took %d (wait %v)", taken, wait)
+	}
+	if taken, _ := b.Take(5, now.Add(time.Hour)); taken != 3 {
+		t.Fatalf("expected bucket refill to be capped by burst 3 but took %d", taken)
+	}
+}
+
+func TestBucketReturn(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBucket(1, 2)
+	b.Take(2, now)
+	b.Return(5)
+	if taken, _ := b.Take(3, now); taken != 2 {
+		t.Fatalf("expected returned tokens to be capped by burst 2 but took %d", taken)
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBucket(10, 1)
+	expected := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i, e := range expected {
+		if wait := b.Reserve(now); wait != e {
+			t.Fatalf("expected reservation %d to wait %v but got %v", i, e, wait)
+		}
+	}
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	b := NewBucket(0, 0)
+	if taken, wait := b.Take(1000, time.Now()); taken != 1000 || wait != 0 {
+		t.Fatalf("expected unlimited bucket to allow all tokens but took %d (wait %v)", taken, wait)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/handler"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/quota"
+	"github.com/marcoshack/schedula/scheduler"
+)
+
+// reloadableFlags are the settings applied by a reload, the others take effect after a restart
+var reloadableFlags = map[string]bool{
+	"w":                 true,
+	"host-rate":         true,
+	"host-burst":        true,
+	"host-max-inflight": true,
+	"host-limits":       true,
+	"callback-allow":    true,
+	"callback-deny":     true,
+	"timeout":           true,
+	"max-pending":       true,
+	"create-rate":       true,
+	"create-burst":      true,
+	"max-data-size":     true,
+	"log-level":         true,
+}
+
+// reloader loads the config file and environment variables again, applying the runtime settings
+// to the running services
+type reloader struct {
+	sync.Mutex
+	scheduler scheduler.Scheduler
+	executor  *callback.SynchronousExecutor
+	quotas    *quota.Jobs
+}
+
+// Reload applies the reloadable settings, returning the ones changed since the last load. An
+// invalid configuration is rejected and the flags keep their current values.
+func (r *reloader) Reload() (handler.ReloadResult, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	previous := flagValues(flag.CommandLine)
+	resetConfigSources(flag.CommandLine, commandLine)
+	ignored, err := loadConfigSources(flag.CommandLine, commandLine, os.Environ())
+	logIgnored(ignored)
+	var c *config
+	if err == nil {
+		c, err = parseConfig()
+	}
+	if err != nil {
+		for name, value := range previous {
+			flag.Set(name, value)
+		}
+		return handler.ReloadResult{}, err
+	}
+
+	result := handler.ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	for name, value := range flagValues(flag.CommandLine) {
+		if value == previous[name] {
+			continue
+		}
+		if reloadableFlags[name] {
+			result.Applied = append(result.Applied, configKey(name))
+		} else {
+			result.RestartRequired = append(result.RestartRequired, configKey(name))
+		}
+	}
+	sort.Strings(result.Applied)
+	sort.Strings(result.RestartRequired)
+
+	level, _ := logging.ParseLevel(c.Logging.Level)
+	logging.SetLevel(level)
+	r.executor.SetTimeout(c.CallbackTimeout)
+	r.quotas.SetDefaults(c.ClientLimits)
+	r.scheduler.Reload(scheduler.RuntimeConfig{
+		WorkersPerHost: c.Scheduler.WorkersPerHost,
+		HostLimits:     c.Scheduler.HostLimits,
+		HostLimitRules: c.Scheduler.HostLimitRules,
+		AllowHosts:     c.Scheduler.AllowHosts,
+		DenyHosts:      c.Scheduler.DenyHosts,
+	})
+	logger.Info("configuration reloaded", "applied", result.Applied, "restart_required", result.RestartRequired)
+	return result, nil
+}
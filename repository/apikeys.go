@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// APIKeys is a repository of the keys used to authenticate API callers
+type APIKeys interface {
+	Add(entity.APIKey) (entity.APIKey, error)
+	FindBySecret(secret string) (entity.APIKey, error)
+	List() ([]entity.APIKey, error)
+	Revoke(id string) (entity.APIKey, error)
+}
+
+// NewAPIKeys creates an API keys repository of the given type.
+func NewAPIKeys(repoType string) (APIKeys, error) {
+	switch repoType {
+	case "in-memory", "in-memory-mutex":
+		return NewAPIKeysInMemory()
+	}
+	return nil, fmt.Errorf("invalid API keys repository type: '%s'", repoType)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.google.com/p/go-uuid/uuid"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// APIKeysInMemory ...
+type APIKeysInMemory struct {
+	sync.RWMutex
+	keysByHash map[string]*entity.APIKey
+	keysByID   map[string]*entity.APIKey
+	keyIndex   []string
+}
+
+// NewAPIKeysInMemory ...
+func NewAPIKeysInMemory() (APIKeys, error) {
+	return &APIKeysInMemory{
+		keysByHash: make(map[string]*entity.APIKey),
+		keysByID:   make(map[string]*entity.APIKey),
+	}, nil
+}
+
+// Add stores the given key, generating its secret if blank. The returned key is the only one
+// holding the secret.
+func (r *APIKeysInMemory) Add(key entity.APIKey) (entity.APIKey, error) {
+	if !key.IsValidRole() {
+		return entity.APIKey{}, fmt.Errorf("invalid API key role: '%s'", key.Role)
+	}
+	if key.Secret == "" {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return entity.APIKey{}, fmt.Errorf("unable to generate API key secret: %v", err)
+		}
+		key.Secret = hex.EncodeToString(secret)
+	}
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now().Unix()
+	key.Revoked = false
+
+	r.Lock()
+	defer r.Unlock()
+
+	hash := hashSecret(key.Secret)
+	if r.keysByHash[hash] != nil {
+		return entity.APIKey{}, fmt.Errorf("API key secret already exists")
+	}
+	stored := key
+	stored.Secret = ""
+	r.keysByHash[hash] = &stored
+	r.keysByID[key.ID] = &stored
+	r.keyIndex = append(r.keyIndex, key.ID)
+
+	return key, nil
+}
+
+// FindBySecret returns the key with the given secret, or an error if it doesn't exist or was revoked
+func (r *APIKeysInMemory) FindBySecret(secret string) (entity.APIKey, error) {
+	r.RLock()
+	defer r.RUnlock()
+	key := r.keysByHash[hashSecret(secret)]
+	if key == nil || key.Revoked {
+		return entity.APIKey{}, fmt.Errorf("invalid API key")
+	}
+	return *key, nil
+}
+
+// List returns all keys in the order they were added, without their secrets
+func (r *APIKeysInMemory) List() ([]entity.APIKey, error) {
+	r.RLock()
+	defer r.RUnlock()
+	keys := make([]entity.APIKey, len(r.keyIndex))
+	for i, id := range r.keyIndex {
+		keys[i] = *r.keysByID[id]
+	}
+	return keys, nil
+}
+
+// Revoke invalidates the key with the given ID
+func (r *APIKeysInMemory) Revoke(id string) (entity.APIKey, error) {
+	r.Lock()
+	defer r.Unlock()
+	key := r.keysByID[id]
+	if key == nil {
+		return entity.APIKey{}, fmt.Errorf("API key ID=%s not found", id)
+	}
+	key.Revoked = true
+	return *key, nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+func Test_APIKeysInMemory_Add(t *testing.T) {
+	repo, _ := NewAPIKeys("in-memory")
+	key, err := repo.Add(entity.APIKey{ClientKey: "foo", Role: entity.RoleClient})
+	if err != nil {
+		t.Fatalf("unable to add API key: %v", err)
+	}
+	if key.ID == "" || key.Secret == "" {
+		t.Fatalf("expected API key ID and secret not to be blank")
+	}
+	found, err := repo.FindBySecret(key.Secret)
+	if err != nil {
+		t.Fatalf("unable to find API key: %v", err)
+	}
+	if found.ID != key.ID || found.ClientKey != "foo" {
+		t.Fatalf("expected API key '%s' of client 'foo' but got '%s' of client '%s'", key.ID, found.ID, found.ClientKey)
+	}
+	if found.Secret != "" {
+		t.Fatalf("expected stored API key not to expose its secret")
+	}
+}
+
+func Test_APIKeysInMemory_AddInvalidRole(t *testing.T) {
+	repo, _ := NewAPIKeys("in-memory")
+	if _, err := repo.Add(entity.APIKey{ClientKey: "foo", Role: "root"}); err == nil {
+		t.Fatalf("expected an error adding an API key with invalid role")
+	}
+}
+
+func Test_APIKeysInMemory_Revoke(t *testing.T) {
+	repo, _ := NewAPIKeys("in-memory")
+	key, _ := repo.Add(entity.APIKey{ClientKey: "foo", Role: entity.RoleClient})
+	if _, err := repo.Revoke(key.ID); err != nil {
+		t.Fatalf("unable to revoke API key: %v", err)
+	}
+	if _, err := repo.FindBySecret(key.Secret); err == nil {
+		t.Fatalf("expected an error finding a revoked API key")
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cursor is the position of the last job of a page in a sorted listing. Value is the job's sort
+// key and Seq is a unique, monotonically increasing sequence assigned when the job was added,
+// used to break ties. Backends with ordered storage can resume a listing from it with a keyset
+// condition like (value, seq) > (cursor.Value, cursor.Seq), so pages stay stable while jobs are
+// added or removed.
+type cursor struct {
+	SortBy    string
+	SortOrder string
+	Value     int64
+	Seq       uint64
+}
+
+// String returns the opaque representation of the cursor handed to clients
+func (c cursor) String() string {
+	raw := fmt.Sprintf("%s|%s|%d|%d", c.SortBy, c.SortOrder, c.Value, c.Seq)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// after returns whether the position (value, seq) comes after the cursor in its sort order
+func (c cursor) after(value int64, seq uint64) bool {
+	if c.SortOrder == SortDesc {
+		return value < c.Value || (value == c.Value && seq < c.Seq)
+	}
+	return value > c.Value || (value == c.Value && seq > c.Seq)
+}
+
+// parseCursor parses the cursor of a listing with the given sort options
+func parseCursor(s string, sortBy string, sortOrder string) (cursor, error) {
+	invalid := fmt.Errorf("invalid cursor: '%s'", s)
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, invalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 {
+		return cursor{}, invalid
+	}
+	value, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return cursor{}, invalid
+	}
+	seq, err := strconv.ParseUint(parts[3], 10, 64)
+	if err != nil {
+		return cursor{}, invalid
+	}
+	c := cursor{SortBy: parts[0], SortOrder: parts[1], Value: value, Seq: seq}
+	if c.SortBy != sortBy || c.SortOrder != sortOrder {
+		return cursor{}, fmt.Errorf("cursor doesn't match the query sort options")
+	}
+	return c, nil
+}
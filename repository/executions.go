@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+const (
+	// DefaultInlineExecutions is the number of latest executions kept inline in a job
+	DefaultInlineExecutions = 10
+
+	// DefaultExecutionRetention is the number of executions kept in the history of a job
+	DefaultExecutionRetention = 1000
+
+	// executionsCursor is the sort field of execution cursors, listed newest first
+	executionsCursor = "executions"
+)
+
+// Config holds repository configuration parameters
+type Config struct {
+	// InlineExecutions is the number of latest executions kept in Job.Executions, the full
+	// history is listed with ListExecutions. Defaults to DefaultInlineExecutions.
+	InlineExecutions int
+	// ExecutionRetention is the number of executions kept in the history of each job, older ones
+	// are discarded. Defaults to DefaultExecutionRetention.
+	ExecutionRetention int
+	// DSN is the connection string of the redis and mysql backends
+	DSN string
+	// Options are the backend specific settings, e.g. its connection pool size or retry policy
+	Options map[string]string
+}
+
+func (c Config) inlineExecutions() int {
+	if c.InlineExecutions <= 0 {
+		return DefaultInlineExecutions
+	}
+	return c.InlineExecutions
+}
+
+func (c Config) executionRetention() int {
+	if c.ExecutionRetention <= 0 {
+		return DefaultExecutionRetention
+	}
+	return c.ExecutionRetention
+}
+
+// ExecutionQuery selects a page of the executions of a job, newest first
+type ExecutionQuery struct {
+	JobID  string
+	Status string
+	Cursor string
+	Limit  int
+}
+
+// ExecutionPage is a page of executions. NextCursor is blank on the last page.
+type ExecutionPage struct {
+	Executions []entity.JobExecution
+	NextCursor string
+}
+
+// Validate checks the query values
+func (q *ExecutionQuery) Validate() error {
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", q.Limit)
+	}
+	if q.Cursor != "" {
+		if _, err := parseCursor(q.Cursor, executionsCursor, SortDesc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executionEntry is an execution in the history of a job along with its insertion sequence
+type executionEntry struct {
+	execution entity.JobExecution
+	seq       uint64
+}
+
+// pageExecutions returns a page of the matching entries, which are in insertion order, newest first
+func pageExecutions(entries []executionEntry, query ExecutionQuery) ExecutionPage {
+	var from *cursor
+	if query.Cursor != "" {
+		c, _ := parseCursor(query.Cursor, executionsCursor, SortDesc)
+		from = &c
+	}
+
+	page := ExecutionPage{Executions: make([]entity.JobExecution, 0)}
+	var lastSeq uint64
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if from != nil && !from.after(0, entry.seq) {
+			continue
+		}
+		if query.Status != "" && entry.execution.Status != query.Status {
+			continue
+		}
+		if query.Limit > 0 && len(page.Executions) == query.Limit {
+			last := cursor{SortBy: executionsCursor, SortOrder: SortDesc, Seq: lastSeq}
+			page.NextCursor = last.String()
+			break
+		}
+		page.Executions = append(page.Executions, entry.execution)
+		lastSeq = entry.seq
+	}
+	return page
+}
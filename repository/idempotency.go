@@ -0,0 +1,45 @@
+package repository
+
+// idempotencyKeys maps the idempotency keys to the IDs of the jobs created with them, forgetting
+// the keys once their window expires so the map doesn't grow with every request
+type idempotencyKeys struct {
+	jobIDs map[string]string
+	// expiring lists the keys in the order they were added, which is roughly the order they
+	// expire since every key has the same window
+	expiring []idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	key       string
+	jobID     string
+	expiresAt int64
+}
+
+func newIdempotencyKeys() *idempotencyKeys {
+	return &idempotencyKeys{jobIDs: make(map[string]string)}
+}
+
+// get returns the ID of the job created with the key, blank if there's none
+func (k *idempotencyKeys) get(key string) string {
+	return k.jobIDs[key]
+}
+
+// add maps the key to the job until expiresAt (epoch)
+func (k *idempotencyKeys) add(key string, jobID string, expiresAt int64) {
+	k.jobIDs[key] = jobID
+	k.expiring = append(k.expiring, idempotencyEntry{key: key, jobID: jobID, expiresAt: expiresAt})
+}
+
+// prune forgets the keys expired at the given timestamp, from the oldest until the first one
+// still valid
+func (k *idempotencyKeys) prune(now int64) {
+	n := 0
+	for ; n < len(k.expiring) && now >= k.expiring[n].expiresAt; n++ {
+		// the key may have been used again by another job since it expired
+		if entry := k.expiring[n]; k.jobIDs[entry.key] == entry.jobID {
+			delete(k.jobIDs, entry.key)
+		}
+	}
+	// the pruned entries are released when add outgrows the slice capacity
+	k.expiring = k.expiring[n:]
+}
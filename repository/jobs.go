@@ -1,31 +1,72 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/marcoshack/schedula/entity"
 )
 
+// ErrIdempotencyKeyExists is returned by Add, along with the existing job, when the job
+// idempotency key was already used by a job whose idempotency window hasn't expired yet.
+var ErrIdempotencyKeyExists = errors.New("job idempotency key already exists")
+
+// ErrUniqueKeyExists is returned by Add, along with the existing job, when there is a pending job
+// with the same ClientKey and UniqueKey that is kept according to the new job conflict policy.
+var ErrUniqueKeyExists = errors.New("a pending job with the same unique key already exists")
+
+// ErrClaimLost is returned when a job isn't claimed by the given owner, either because the claim
+// expired and was taken over or because the job was rescheduled.
+var ErrClaimLost = errors.New("job claim lost")
+
 // Jobs ...
 type Jobs interface {
 	Add(entity.Job) (entity.Job, error)
+	AddAll([]entity.Job) ([]entity.Job, []error)
 	Get(id string) (entity.Job, error)
-	List(skip int, limit int) ([]entity.Job, error)
+	List(query JobQuery) (JobPage, error)
 	Remove(jobID string) (entity.Job, error)
 	Cancel(jobID string) (entity.Job, error)
+	Reschedule(jobID string, timestamp int64) (entity.Job, error)
+	// Pause holds a pending job, releasing its claim, until it's resumed.
+	Pause(jobID string, now time.Time) (entity.Job, error)
+	// Resume makes a paused job pending again, applying the missed policy if its run was missed.
+	Resume(jobID string, missed string, now time.Time) (entity.Job, error)
 	AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error)
-	Count() int
+	// AppendExecution adds the execution to the job history without changing its status.
+	AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error)
+	// ListExecutions returns a page of the execution history of a job, newest first. Jobs only
+	// keep their latest executions inline.
+	ListExecutions(query ExecutionQuery) (ExecutionPage, error)
+	Count(filter JobFilter) int
 	ListBySchedule(timestamp int64) ([]entity.Job, error)
+	// ClaimDue claims for the owner the pending jobs scheduled at the given timestamp, the ones
+	// scheduled since at a timestamp already claimed and the ones whose claim expired, until ttl
+	// after now.
+	ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error)
+	// Heartbeat renews the owner claim on the job until ttl after now.
+	Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error)
+	// Complete adds the execution of a job claimed by the owner and releases the claim. The
+	// execution of a job paused since it was claimed is added leaving the job paused.
+	Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error)
+	// Ping checks the repository backend can be reached.
+	Ping() error
 }
 
-// New creates a repository instance of the given type.
+// New creates a repository instance of the given type with the default configuration.
 func New(repoType string) (Jobs, error) {
+	return NewWithConfig(repoType, Config{})
+}
+
+// NewWithConfig creates a repository instance of the given type and configuration.
+func NewWithConfig(repoType string, c Config) (Jobs, error) {
 	switch repoType {
 	case "in-memory", "in-memory-mutex": // in-memory default, for now
-		return NewJobsInMemoryWithMutex()
+		return NewJobsInMemoryWithMutexConfig(c)
 	case "in-memory-ch":
-		return NewJobsInMemoryWithChannels()
+		// the scheduler claims the jobs it runs, which this repository doesn't implement yet
+		return nil, fmt.Errorf("repository type 'in-memory-ch' not supported, use 'in-memory'")
 	case "redis":
 		return NewJobsRedis()
 	case "mysql":
@@ -33,3 +74,67 @@ func New(repoType string) (Jobs, error) {
 	}
 	return nil, fmt.Errorf("invalid repository type: '%s'", repoType)
 }
+
+// CheckType returns an error unless the repository type holds everything the server keeps in it:
+// the jobs along with their claims, the pauses, the API keys and the leases
+func CheckType(repoType string) error {
+	switch repoType {
+	case "in-memory", "in-memory-mutex":
+		return nil
+	case "redis", "mysql":
+		// only the jobs are stored there for now, and their claims aren't
+		return fmt.Errorf("repository type '%s' doesn't hold job claims, pauses, API keys and leases yet, use 'in-memory'", repoType)
+	}
+	return fmt.Errorf("invalid repository type: '%s'", repoType)
+}
+
+// executionID identifies the run of the job scheduled at its NextRun
+func executionID(job *entity.Job) string {
+	return fmt.Sprintf("%s-%d", job.ID, job.NextRun)
+}
+
+// uniqueKey returns the key that identifies the job among the pending jobs of its client
+func uniqueKey(job *entity.Job) string {
+	return fmt.Sprintf("%s/%s", job.ClientKey, job.UniqueKey)
+}
+
+// resolveConflict applies the conflict policy of a job being added with the unique key of an
+// existing one, returning whether the existing job has to be replaced by the new one or
+// ErrUniqueKeyExists if it has to be kept.
+func resolveConflict(existing *entity.Job, job *entity.Job) (bool, error) {
+	policy, err := job.ConflictPolicy()
+	if err != nil {
+		return false, err
+	}
+	if existing == nil || (existing.Status != entity.JobStatusPending && existing.Status != entity.JobStatusPaused) {
+		return false, nil
+	}
+	switch policy {
+	case entity.JobConflictReplace:
+		return true, nil
+	case entity.JobConflictKeepEarliest:
+		existingTime, _ := existing.Schedule.NextTimestamp()
+		jobTime, err := job.Schedule.NextTimestamp()
+		if err == nil && jobTime < existingTime {
+			return true, nil
+		}
+	}
+	return false, ErrUniqueKeyExists
+}
+
+// resume makes the paused job pending again. When its run was missed while paused the missed
+// policy is applied: the skip policy ends the job returning the skipped execution, while the
+// others keep it pending for the backend to run it as soon as possible. A timestamp schedule has
+// a single occurrence, so JobMissedRunOnce and JobMissedRunAll both run it once.
+func resume(job *entity.Job, missed string, now time.Time) (*entity.JobExecution, error) {
+	if !entity.IsValidMissedPolicy(missed) {
+		return nil, fmt.Errorf("invalid missed runs policy: '%s'", missed)
+	}
+	job.Status = entity.JobStatusPending
+	job.PausedAt = 0
+	if job.NextRun <= now.Unix() && missed == entity.JobMissedSkip {
+		job.Status = entity.JobStatusSkipped
+		return &entity.JobExecution{Timestamp: now.Unix(), Status: entity.JobStatusSkipped, Message: "missed while paused"}, nil
+	}
+	return nil, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,10 +10,15 @@ import (
 	"github.com/marcoshack/schedula/entity"
 )
 
+// errNotSupported is returned by the operations JobsInMemoryWithChannels doesn't implement yet
+var errNotSupported = errors.New("operation not supported by the in-memory-ch repository")
+
 // JobsInMemoryWithChannels ...
 type JobsInMemoryWithChannels struct {
 	JobsByID       map[string]*entity.Job
 	JobsBySchedule map[int64][]*entity.Job
+	idemKeys       *idempotencyKeys
+	jobIDByUniqKey map[string]string
 	requests       chan request
 }
 
@@ -32,6 +38,8 @@ func NewJobsInMemoryWithChannels() (*JobsInMemoryWithChannels, error) {
 	repo := &JobsInMemoryWithChannels{
 		JobsByID:       make(map[string]*entity.Job),
 		JobsBySchedule: make(map[int64][]*entity.Job),
+		idemKeys:       newIdempotencyKeys(),
+		jobIDByUniqKey: make(map[string]string),
 		requests:       make(chan request),
 	}
 	go repo.handle()
@@ -56,15 +64,47 @@ func (r *JobsInMemoryWithChannels) add(job *entity.Job, res chan response) {
 		return
 	}
 
+	now := time.Now().Unix()
+	r.idemKeys.prune(now)
+	if job.Idempotency != nil {
+		if existing := r.JobsByID[r.idemKeys.get(job.Idempotency.Key)]; existing != nil && !existing.Idempotency.IsExpired(now) {
+			res <- response{job: existing, err: ErrIdempotencyKeyExists}
+			return
+		}
+	}
+	replaced := ""
+	if job.UniqueKey != "" {
+		existing := r.JobsByID[r.jobIDByUniqKey[uniqueKey(job)]]
+		replace, err := resolveConflict(existing, job)
+		if err != nil {
+			res <- response{job: existing, err: err}
+			return
+		}
+		if replace {
+			existing.Status = entity.JobStatusCanceled
+			replaced = existing.ID
+		}
+	}
+
 	job.ID = uuid.New()
 	job.Status = entity.JobStatusPending
+	job.CreatedAt = now
+	job.NextRun = timestamp
 	r.JobsByID[job.ID] = job
+	if job.Idempotency != nil {
+		r.idemKeys.add(job.Idempotency.Key, job.ID, job.Idempotency.ExpiresAt)
+	}
+	if job.UniqueKey != "" {
+		r.jobIDByUniqKey[uniqueKey(job)] = job.ID
+	}
 
 	if r.JobsBySchedule[timestamp] == nil {
 		r.JobsBySchedule[timestamp] = make([]*entity.Job, 0)
 	}
 	r.JobsBySchedule[timestamp] = append(r.JobsBySchedule[timestamp], job)
-	res <- response{job: job, err: nil}
+	added := *job
+	added.ReplacedID = replaced
+	res <- response{job: &added, err: nil}
 }
 
 func (r *JobsInMemoryWithChannels) get(job *entity.Job, res chan response) {
@@ -90,6 +130,9 @@ func (r *JobsInMemoryWithChannels) execute(req request) (entity.Job, error) {
 	req.response = make(chan response, 1)
 	r.requests <- req
 	res := <-req.response
+	if (res.err == ErrIdempotencyKeyExists || res.err == ErrUniqueKeyExists) && res.job != nil {
+		return *res.job, res.err
+	}
 	if res.err != nil {
 		return entity.Job{}, res.err
 	}
@@ -101,6 +144,28 @@ func (r *JobsInMemoryWithChannels) Add(job entity.Job) (entity.Job, error) {
 	return r.execute(request{f: r.add, job: &job})
 }
 
+// AddAll adds the given jobs in a single request to the repository handler
+func (r *JobsInMemoryWithChannels) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	res := make([]entity.Job, len(jobs))
+	errs := make([]error, len(jobs))
+	addAll := func(_ *entity.Job, done chan response) {
+		for i := range jobs {
+			// the repository keeps its own copy, not the caller's slice element
+			job := jobs[i]
+			added := make(chan response, 1)
+			r.add(&job, added)
+			if out := <-added; out.err != nil {
+				errs[i] = out.err
+			} else {
+				res[i] = *out.job
+			}
+		}
+		done <- response{job: &entity.Job{}}
+	}
+	r.execute(request{f: addAll})
+	return res, errs
+}
+
 // Get ...
 func (r *JobsInMemoryWithChannels) Get(jobID string) (entity.Job, error) {
 	return r.execute(request{f: r.get, job: &entity.Job{ID: jobID}})
@@ -119,8 +184,13 @@ func (r *JobsInMemoryWithChannels) Cancel(jobID string) (entity.Job, error) {
 
 // List ...
 // TODO implementation pending
-func (r *JobsInMemoryWithChannels) List(skip int, limit int) ([]entity.Job, error) {
-	return make([]entity.Job, 0), nil
+func (r *JobsInMemoryWithChannels) List(query JobQuery) (JobPage, error) {
+	return JobPage{Jobs: make([]entity.Job, 0)}, nil
+}
+
+// Reschedule ...
+func (r *JobsInMemoryWithChannels) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
 }
 
 // AddExecution ...
@@ -131,7 +201,7 @@ func (r *JobsInMemoryWithChannels) AddExecution(jobID string, date time.Time, st
 
 // Count ...
 // TODO implementation pending
-func (r *JobsInMemoryWithChannels) Count() int {
+func (r *JobsInMemoryWithChannels) Count(filter JobFilter) int {
 	return len(r.JobsByID)
 }
 
@@ -140,3 +210,44 @@ func (r *JobsInMemoryWithChannels) Count() int {
 func (r *JobsInMemoryWithChannels) ListBySchedule(timestamp int64) ([]entity.Job, error) {
 	return make([]entity.Job, 0), nil
 }
+
+// ClaimDue ...
+func (r *JobsInMemoryWithChannels) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	return nil, errNotSupported
+}
+
+// Heartbeat ...
+func (r *JobsInMemoryWithChannels) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
+}
+
+// Complete ...
+func (r *JobsInMemoryWithChannels) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
+}
+
+// Pause ...
+func (r *JobsInMemoryWithChannels) Pause(jobID string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
+}
+
+// Resume ...
+func (r *JobsInMemoryWithChannels) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
+}
+
+// AppendExecution ...
+func (r *JobsInMemoryWithChannels) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	return entity.Job{}, errNotSupported
+}
+
+// ListExecutions ...
+// TODO implementation pending
+func (r *JobsInMemoryWithChannels) ListExecutions(query ExecutionQuery) (ExecutionPage, error) {
+	return ExecutionPage{Executions: make([]entity.JobExecution, 0)}, nil
+}
+
+// Ping ...
+func (r *JobsInMemoryWithChannels) Ping() error {
+	return nil
+}
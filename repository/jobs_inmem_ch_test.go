@@ -34,6 +34,20 @@ func Test_JobsInMemoryWithChannels_Add(t *testing.T) {
 	}
 }
 
+func Test_JobsInMemoryWithChannels_AddAllCopiesJobs(t *testing.T) {
+	repo, _ := NewJobsInMemoryWithChannels()
+	jobs := []entity.Job{{ClientKey: "foo", Schedule: entity.JobSchedule{Format: "timestamp", Value: "1234567890"}}}
+	added, errs := repo.AddAll(jobs)
+	if errs[0] != nil {
+		t.Fatalf("error creating job: %v", errs[0])
+	}
+
+	jobs[0].ClientKey = "bar"
+	if stored, _ := repo.Get(added[0].ID); stored.ClientKey != "foo" {
+		t.Fatalf("expected the repository not to share the caller's jobs but got client '%s'", stored.ClientKey)
+	}
+}
+
 func Test_JobsInMemoryWithChannels_Get(t *testing.T) {
 	repo, _ := NewJobsInMemoryWithChannels()
 	repo.JobsByID["foo"] = &entity.Job{ID: "foo"}
@@ -71,3 +85,30 @@ func Test_JobsInMemoryWithChannels_Cancel(t *testing.T) {
 		t.Fatalf("expected job status in JobsByID to be '%s' but got '%s'", entity.JobStatusCanceled, job.Status)
 	}
 }
+
+func Test_JobsInMemoryWithChannels_AddWithUniqueKey(t *testing.T) {
+	repo, _ := NewJobsInMemoryWithChannels()
+	job := entity.Job{
+		ClientKey: "foo",
+		UniqueKey: "order-123",
+		Schedule:  entity.JobSchedule{Format: "timestamp", Value: fmt.Sprintf("%v", time.Now().Unix())},
+	}
+	first, _ := repo.Add(job)
+	second, err := repo.Add(job)
+	if err != ErrUniqueKeyExists {
+		t.Fatalf("expected error to be '%v' but got '%v'", ErrUniqueKeyExists, err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("expected existing job '%s' but got '%s'", first.ID, second.ID)
+	}
+}
+
+func Test_JobsInMemoryWithChannels_NotSupportedByNew(t *testing.T) {
+	if _, err := New("in-memory-ch"); err == nil {
+		t.Fatalf("expected error creating in-memory-ch repository, which doesn't claim jobs")
+	}
+	repo, _ := NewJobsInMemoryWithChannels()
+	if _, err := repo.ClaimDue(1234567890, "node-1", time.Now(), time.Second); err == nil {
+		t.Fatalf("expected error claiming jobs")
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -15,43 +16,124 @@ type JobsInMemoryWithMutex struct {
 	jobsByID       map[string]*entity.Job
 	jobsBySchedule map[int64][]*entity.Job
 	jobIndexByID   []string
+	jobSeqByID     map[string]uint64
+	idemKeys       *idempotencyKeys
+	jobIDByUniqKey map[string]string
+	claimedJobs    map[string]*entity.Job
+	// overdueJobs holds the jobs scheduled at or before claimedUpTo, the last timestamp claimed,
+	// whose schedule bucket won't be claimed again
+	overdueJobs  map[string]*entity.Job
+	claimedUpTo  int64
+	seq          uint64
+	executions   map[string][]executionEntry
+	executionSeq uint64
+	config       Config
 }
 
 // NewJobsInMemoryWithMutex ...
 func NewJobsInMemoryWithMutex() (Jobs, error) {
+	return NewJobsInMemoryWithMutexConfig(Config{})
+}
+
+// NewJobsInMemoryWithMutexConfig ...
+func NewJobsInMemoryWithMutexConfig(c Config) (Jobs, error) {
 	return &JobsInMemoryWithMutex{
 		jobsByID:       make(map[string]*entity.Job),
 		jobsBySchedule: make(map[int64][]*entity.Job),
+		jobSeqByID:     make(map[string]uint64),
+		idemKeys:       newIdempotencyKeys(),
+		jobIDByUniqKey: make(map[string]string),
+		claimedJobs:    make(map[string]*entity.Job),
+		overdueJobs:    make(map[string]*entity.Job),
+		executions:     make(map[string][]executionEntry),
+		config:         c,
 	}, nil
 }
 
 // Add ...
 func (r *JobsInMemoryWithMutex) Add(job entity.Job) (entity.Job, error) {
-	job.ID = uuid.New()
-	job.Status = entity.JobStatusPending
+	r.Lock()
+	defer r.Unlock()
+	return r.add(job)
+}
+
+// AddAll adds the given jobs holding the lock only once. Jobs that can't be added have the
+// corresponding error set.
+func (r *JobsInMemoryWithMutex) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	res := make([]entity.Job, len(jobs))
+	errs := make([]error, len(jobs))
 
 	r.Lock()
 	defer r.Unlock()
 
-	r.jobIndexByID = append(r.jobIndexByID, job.ID)
-	r.jobsByID[job.ID] = &job
+	for i, job := range jobs {
+		res[i], errs[i] = r.add(job)
+	}
+	return res, errs
+}
 
+// add validates the job schedule before resolving conflicts or writing any index, so an invalid
+// job leaves the repository unchanged. The caller must hold the lock.
+func (r *JobsInMemoryWithMutex) add(job entity.Job) (entity.Job, error) {
 	jobTime, err := job.Schedule.NextTimestamp()
 	if err != nil {
-		return entity.Job{}, err
+		return entity.Job{}, fmt.Errorf("invalid job schedule: %v", err)
 	}
-	if r.jobsBySchedule[jobTime] == nil {
-		r.jobsBySchedule[jobTime] = make([]*entity.Job, 0)
+	now := time.Now().Unix()
+	r.idemKeys.prune(now)
+	if job.Idempotency != nil {
+		if existing := r.jobsByID[r.idemKeys.get(job.Idempotency.Key)]; existing != nil && !existing.Idempotency.IsExpired(now) {
+			return *existing, ErrIdempotencyKeyExists
+		}
+	}
+	var replaced *entity.Job
+	if job.UniqueKey != "" {
+		existing := r.jobsByID[r.jobIDByUniqKey[uniqueKey(&job)]]
+		replace, err := resolveConflict(existing, &job)
+		if err != nil {
+			if existing != nil {
+				return *existing, err
+			}
+			return entity.Job{}, err
+		}
+		if replace {
+			replaced = existing
+		}
 	}
-	r.jobsBySchedule[jobTime] = append(r.jobsBySchedule[jobTime], &job)
 
-	return job, nil
+	if replaced != nil {
+		r.cancel(replaced)
+	}
+	job.ID = uuid.New()
+	job.Status = entity.JobStatusPending
+	job.CreatedAt = now
+	job.NextRun = jobTime
+	if job.Idempotency != nil {
+		r.idemKeys.add(job.Idempotency.Key, job.ID, job.Idempotency.ExpiresAt)
+	}
+	if job.UniqueKey != "" {
+		r.jobIDByUniqKey[uniqueKey(&job)] = job.ID
+	}
+
+	r.seq++
+	r.jobIndexByID = append(r.jobIndexByID, job.ID)
+	r.jobsByID[job.ID] = &job
+	r.jobSeqByID[job.ID] = r.seq
+	r.schedule(&job)
+
+	res := job
+	if replaced != nil {
+		res.ReplacedID = replaced.ID
+	}
+	return res, nil
 }
 
 // Get returns the Job associated with the given id or nil if it doensn't exist
 func (r *JobsInMemoryWithMutex) Get(id string) (entity.Job, error) {
-	job, _ := r.get(id)
-	if job != nil {
+	r.RLock()
+	defer r.RUnlock()
+	// the job is copied under the lock, as the scheduler updates it while running
+	if job := r.jobsByID[id]; job != nil {
 		return *job, nil
 	}
 	return entity.Job{}, nil
@@ -63,39 +145,91 @@ func (r *JobsInMemoryWithMutex) get(id string) (*entity.Job, error) {
 	return r.jobsByID[id], nil
 }
 
-// List returns the list of scheduled jobs
-func (r *JobsInMemoryWithMutex) List(skip int, limit int) ([]entity.Job, error) {
+// List returns a page of the scheduled jobs matching the query filter, in the requested order
+func (r *JobsInMemoryWithMutex) List(query JobQuery) (JobPage, error) {
+	if err := query.Validate(); err != nil {
+		return JobPage{}, err
+	}
+
+	var from *cursor
+	if query.Cursor != "" {
+		c, err := parseCursor(query.Cursor, query.SortBy, query.SortOrder)
+		if err != nil {
+			return JobPage{}, err
+		}
+		from = &c
+	}
+
 	r.RLock()
 	defer r.RUnlock()
 
 	// empty result
-	if len(r.jobsByID) == 0 || skip > len(r.jobsByID) || limit < 0 {
-		return make([]entity.Job, 0), nil
+	if len(r.jobsByID) == 0 || query.Skip > len(r.jobsByID) || query.Limit < 0 {
+		return JobPage{Jobs: make([]entity.Job, 0)}, nil
+	}
+
+	matches := make([]jobEntry, 0)
+	for _, id := range r.jobIndexByID {
+		job := r.jobsByID[id]
+		if !query.Filter.Match(job) {
+			continue
+		}
+		entry := jobEntry{job: job, seq: r.jobSeqByID[id]}
+		if from != nil && !from.after(sortKey(job, query.SortBy), entry.seq) {
+			continue
+		}
+		matches = append(matches, entry)
 	}
+	sortJobs(matches, query.SortBy, query.SortOrder)
 
-	start := skip
-	if start > len(r.jobIndexByID) {
-		skip = len(r.jobIndexByID)
+	start := query.Skip
+	if start > len(matches) {
+		start = len(matches)
 	}
-	end := skip + limit
-	if end > len(r.jobIndexByID) {
-		end = len(r.jobIndexByID)
+	end := start + query.Limit
+	if end > len(matches) {
+		end = len(matches)
 	}
 
-	ids := r.jobIndexByID[start:end]
-	jobs := make([]entity.Job, len(ids))
-	for i := 0; i < len(ids); i++ {
-		jobs[i] = *r.jobsByID[ids[i]]
+	page := JobPage{Jobs: make([]entity.Job, end-start)}
+	for i := range page.Jobs {
+		page.Jobs[i] = *matches[start+i].job
+	}
+	if end < len(matches) && end > 0 {
+		last := matches[end-1]
+		page.NextCursor = cursor{
+			SortBy:    query.SortBy,
+			SortOrder: query.SortOrder,
+			Value:     sortKey(last.job, query.SortBy),
+			Seq:       last.seq,
+		}.String()
 	}
 
-	return jobs, nil
+	return page, nil
 }
 
-// Count returns the number of active jobs in the scheduler
-func (r *JobsInMemoryWithMutex) Count() int {
+// Count returns the number of jobs in the repository matching the given filter
+func (r *JobsInMemoryWithMutex) Count(filter JobFilter) int {
 	r.RLock()
 	defer r.RUnlock()
-	return len(r.jobIndexByID)
+	if filter == (JobFilter{}) {
+		return len(r.jobIndexByID)
+	}
+	if filter.UniqueKey != "" && filter.ClientKey != "" && filter.Unfinished {
+		// a single unfinished job of the client has the key, the indexed one
+		job := r.jobsByID[r.jobIDByUniqKey[uniqueKey(&entity.Job{ClientKey: filter.ClientKey, UniqueKey: filter.UniqueKey})]]
+		if job != nil && filter.Match(job) {
+			return 1
+		}
+		return 0
+	}
+	count := 0
+	for _, id := range r.jobIndexByID {
+		if filter.Match(r.jobsByID[id]) {
+			count++
+		}
+	}
+	return count
 }
 
 // ListBySchedule returns the list of Jobs scheduled for the given timestamp
@@ -119,8 +253,7 @@ func (r *JobsInMemoryWithMutex) Remove(jobID string) (entity.Job, error) {
 		return *job, err
 	}
 
-	jobTimestamp, err := job.Schedule.NextTimestamp()
-	if err != nil {
+	if _, err := job.Schedule.NextTimestamp(); err != nil {
 		return *job, err
 	}
 
@@ -129,6 +262,9 @@ func (r *JobsInMemoryWithMutex) Remove(jobID string) (entity.Job, error) {
 
 	// remove from r.jobsByID
 	delete(r.jobsByID, jobID)
+	delete(r.jobSeqByID, jobID)
+	delete(r.claimedJobs, jobID)
+	delete(r.executions, jobID)
 
 	// rebuild r.jobIndexByID
 	// TODO use append to rebuild
@@ -142,38 +278,284 @@ func (r *JobsInMemoryWithMutex) Remove(jobID string) (entity.Job, error) {
 	r.jobIndexByID = newJobIndex
 
 	// remove from r.jobsBySchedule
-	// TODO use append to rebuild
-	scheduledJobs := r.jobsBySchedule[jobTimestamp]
-	newScheduledJobs := make([]*entity.Job, len(scheduledJobs)-1)
-	for i, j := 0, 0; i < len(newScheduledJobs); i, j = i+1, j+1 {
-		if scheduledJobs[i].ID == jobID {
-			scheduledJobs[j] = nil
-			j++
-		}
-		newScheduledJobs[i] = scheduledJobs[j]
+	r.unschedule(job)
+
+	return *job, nil
+}
+
+// Reschedule changes the time a pending job will run, without changing its schedule
+func (r *JobsInMemoryWithMutex) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	if job.Status != entity.JobStatusPending {
+		return entity.Job{}, fmt.Errorf("job ID=%s cannot be rescheduled with status '%s'", jobID, job.Status)
 	}
-	r.jobsBySchedule[jobTimestamp] = newScheduledJobs
+	r.unschedule(job)
+	job.NextRun = timestamp
+	job.Claim = nil
+	delete(r.claimedJobs, jobID)
+	r.schedule(job)
+	return *job, nil
+}
+
+// Pause ...
+func (r *JobsInMemoryWithMutex) Pause(jobID string, now time.Time) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
 
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	if job.Status == entity.JobStatusPaused {
+		return *job, nil
+	}
+	if job.Status != entity.JobStatusPending {
+		return entity.Job{}, fmt.Errorf("job ID=%s cannot be paused with status '%s'", jobID, job.Status)
+	}
+	job.Status = entity.JobStatusPaused
+	job.PausedAt = now.Unix()
+	job.Claim = nil
+	delete(r.claimedJobs, jobID)
 	return *job, nil
 }
 
-// Cancel changes job status to JobStatusCanceled
-func (r *JobsInMemoryWithMutex) Cancel(jobID string) (entity.Job, error) {
-	job, err := r.get(jobID)
+// Resume ...
+func (r *JobsInMemoryWithMutex) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	if job.Status != entity.JobStatusPaused {
+		return entity.Job{}, fmt.Errorf("job ID=%s cannot be resumed with status '%s'", jobID, job.Status)
+	}
+	skipped, err := resume(job, missed, now)
 	if err != nil {
 		return entity.Job{}, err
 	}
-	job.Status = entity.JobStatusCanceled
+	if skipped != nil {
+		r.addExecution(job, *skipped)
+	}
+	if job.Status == entity.JobStatusPending && job.NextRun <= now.Unix() {
+		r.unschedule(job)
+		job.NextRun = now.Unix() + 1
+		r.schedule(job)
+	}
+	return *job, nil
+}
+
+// schedule adds the job to r.jobsBySchedule at its NextRun time
+func (r *JobsInMemoryWithMutex) schedule(job *entity.Job) {
+	r.jobsBySchedule[job.NextRun] = append(r.jobsBySchedule[job.NextRun], job)
+	if r.claimedUpTo > 0 && job.NextRun <= r.claimedUpTo {
+		r.overdueJobs[job.ID] = job
+	}
+}
+
+// unschedule removes the job from r.jobsBySchedule
+func (r *JobsInMemoryWithMutex) unschedule(job *entity.Job) {
+	delete(r.overdueJobs, job.ID)
+	scheduledJobs := r.jobsBySchedule[job.NextRun]
+	newScheduledJobs := make([]*entity.Job, 0, len(scheduledJobs))
+	for _, scheduledJob := range scheduledJobs {
+		if scheduledJob.ID != job.ID {
+			newScheduledJobs = append(newScheduledJobs, scheduledJob)
+		}
+	}
+	if len(newScheduledJobs) == 0 {
+		delete(r.jobsBySchedule, job.NextRun)
+		return
+	}
+	r.jobsBySchedule[job.NextRun] = newScheduledJobs
+}
+
+// Cancel changes job status to JobStatusCanceled
+func (r *JobsInMemoryWithMutex) Cancel(jobID string) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	r.cancel(job)
 	return *job, nil
 }
 
+// cancel changes job status to JobStatusCanceled and releases its claim. The caller must hold the
+// lock.
+func (r *JobsInMemoryWithMutex) cancel(job *entity.Job) {
+	job.Status = entity.JobStatusCanceled
+	job.Claim = nil
+	delete(r.claimedJobs, job.ID)
+}
+
 // AddExecution ...
 func (r *JobsInMemoryWithMutex) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
-	job, err := r.get(jobID)
+	r.Lock()
+	defer r.Unlock()
+
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	r.addExecution(job, entity.JobExecution{Timestamp: date.Unix(), Status: status, Message: message})
+	job.Status = status
+	return *job, nil
+}
+
+// AppendExecution ...
+func (r *JobsInMemoryWithMutex) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return entity.Job{}, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	r.addExecution(job, execution)
+	return *job, nil
+}
+
+// ListExecutions ...
+func (r *JobsInMemoryWithMutex) ListExecutions(query ExecutionQuery) (ExecutionPage, error) {
+	if err := query.Validate(); err != nil {
+		return ExecutionPage{}, err
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.jobsByID[query.JobID] == nil {
+		return ExecutionPage{}, fmt.Errorf("job ID=%s not found", query.JobID)
+	}
+	return pageExecutions(r.executions[query.JobID], query), nil
+}
+
+// addExecution adds the execution to the job history, keeping the latest ones inline and
+// discarding the ones beyond the retention limit. The caller must hold the lock.
+func (r *JobsInMemoryWithMutex) addExecution(job *entity.Job, execution entity.JobExecution) {
+	r.executionSeq++
+	history := append(r.executions[job.ID], executionEntry{execution: execution, seq: r.executionSeq})
+	if retention := r.config.executionRetention(); len(history) > retention {
+		history = append([]executionEntry(nil), history[len(history)-retention:]...)
+	}
+	r.executions[job.ID] = history
+
+	inline := append(job.Executions, execution)
+	if n := r.config.inlineExecutions(); len(inline) > n {
+		inline = append([]entity.JobExecution(nil), inline[len(inline)-n:]...)
+	}
+	job.Executions = inline
+}
+
+// ClaimDue claims the pending jobs scheduled at the given timestamp that weren't claimed yet and
+// takes over the expired claims of pending jobs
+func (r *JobsInMemoryWithMutex) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	expiresAt := now.Add(ttl).Unix()
+	res := make([]entity.Job, 0)
+	claim := func(job *entity.Job) {
+		attempt := 1
+		if job.Claim != nil {
+			attempt = job.Claim.Attempt + 1
+		}
+		job.Claim = &entity.JobClaim{ExecutionID: executionID(job), Owner: owner, ExpiresAt: expiresAt, Attempt: attempt}
+		r.claimedJobs[job.ID] = job
+		res = append(res, *job)
+	}
+
+	for _, job := range r.jobsBySchedule[timestamp] {
+		if job.Status == entity.JobStatusPending && job.Claim == nil {
+			claim(job)
+		}
+	}
+	// jobs deferred or rescheduled to a timestamp already claimed are claimed with the next one
+	for id, job := range r.overdueJobs {
+		if job.NextRun > timestamp {
+			continue
+		}
+		delete(r.overdueJobs, id)
+		if job.Status == entity.JobStatusPending && job.Claim == nil {
+			claim(job)
+		}
+	}
+	if timestamp > r.claimedUpTo {
+		r.claimedUpTo = timestamp
+	}
+	for id, job := range r.claimedJobs {
+		if job.Status != entity.JobStatusPending {
+			delete(r.claimedJobs, id)
+			continue
+		}
+		if job.Claim.IsExpired(now.Unix()) {
+			claim(job)
+		}
+	}
+	return res, nil
+}
+
+// Heartbeat ...
+func (r *JobsInMemoryWithMutex) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	job, err := r.claimed(jobID, owner)
 	if err != nil {
 		return entity.Job{}, err
 	}
-	job.Executions = append(job.Executions, entity.JobExecution{Timestamp: date.Unix(), Status: status, Message: message})
-	job.Status = status
+	job.Claim = &entity.JobClaim{ExecutionID: job.Claim.ExecutionID, Owner: owner, ExpiresAt: now.Add(ttl).Unix(), Attempt: job.Claim.Attempt}
 	return *job, nil
 }
+
+// Complete ...
+func (r *JobsInMemoryWithMutex) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	execution := entity.JobExecution{Timestamp: date.Unix(), Status: status, Message: message}
+	// pausing a job releases its claim, but the callback being sent when it was paused ran all the
+	// same, so its execution is kept while the job stays paused
+	if job := r.jobsByID[jobID]; job != nil && job.Status == entity.JobStatusPaused && job.Claim == nil {
+		r.addExecution(job, execution)
+		return *job, nil
+	}
+	job, err := r.claimed(jobID, owner)
+	if err != nil {
+		return entity.Job{}, err
+	}
+	r.addExecution(job, execution)
+	if job.Status == entity.JobStatusPending {
+		job.Status = status
+	}
+	job.Claim = nil
+	delete(r.claimedJobs, jobID)
+	return *job, nil
+}
+
+// claimed returns the job claimed by the owner, the caller must hold the lock
+func (r *JobsInMemoryWithMutex) claimed(jobID string, owner string) (*entity.Job, error) {
+	job := r.jobsByID[jobID]
+	if job == nil {
+		return nil, fmt.Errorf("job ID=%s not found", jobID)
+	}
+	if job.Claim == nil || job.Claim.Owner != owner {
+		return nil, ErrClaimLost
+	}
+	return job, nil
+}
+
+// Ping always succeeds, as the jobs are kept in the process memory
+func (r *JobsInMemoryWithMutex) Ping() error {
+	return nil
+}
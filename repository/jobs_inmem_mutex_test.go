@@ -28,9 +28,148 @@ func Test_JobsInMemoryWithMutex_Add(t *testing.T) {
 	}
 }
 
+func Test_JobsInMemoryWithMutex_AddAll(t *testing.T) {
+	repo, _ := New("in-memory")
+	jobs, errs := repo.AddAll([]entity.Job{aJob(), {}, aJob()})
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("unable to add jobs: %v", errs)
+	}
+	if errs[1] == nil {
+		t.Fatalf("expected an error for a job without schedule")
+	}
+	if jobs[0].ID == "" || jobs[2].ID == "" || jobs[0].ID == jobs[2].ID {
+		t.Fatalf("invalid job IDs: '%s', '%s'", jobs[0].ID, jobs[2].ID)
+	}
+	if repo.Count(JobFilter{}) != 2 {
+		t.Fatalf("expected repo size to be 2 but got %d", repo.Count(JobFilter{}))
+	}
+}
+
+func Test_JobsInMemoryWithMutex_AddWithIdempotencyKey(t *testing.T) {
+	repo, _ := New("in-memory")
+	job := aJob()
+	job.Idempotency = &entity.JobIdempotency{Key: "foo", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	first, err := repo.Add(job)
+	if err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+	second, err := repo.Add(job)
+	if err != ErrIdempotencyKeyExists {
+		t.Fatalf("expected error to be '%v' but got '%v'", ErrIdempotencyKeyExists, err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("expected existing job '%s' but got '%s'", first.ID, second.ID)
+	}
+	if repo.Count(JobFilter{}) != 1 {
+		t.Fatalf("expected repo size to be 1 but got %d", repo.Count(JobFilter{}))
+	}
+}
+
+func Test_JobsInMemoryWithMutex_AddWithExpiredIdempotencyKey(t *testing.T) {
+	repo, _ := New("in-memory")
+	job := aJob()
+	job.Idempotency = &entity.JobIdempotency{Key: "foo", ExpiresAt: time.Now().Add(-time.Second).Unix()}
+	first, _ := repo.Add(job)
+	second, err := repo.Add(job)
+	if err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("expected a new job for an expired idempotency key")
+	}
+}
+
+func Test_JobsInMemoryWithMutex_PrunesExpiredIdempotencyKeys(t *testing.T) {
+	repo, _ := NewJobsInMemoryWithMutex()
+	keys := repo.(*JobsInMemoryWithMutex).idemKeys
+	job := aJob()
+	for i := 0; i < 3; i++ {
+		job.Idempotency = &entity.JobIdempotency{Key: fmt.Sprintf("expired-%d", i), ExpiresAt: time.Now().Add(-time.Second).Unix()}
+		repo.Add(job)
+	}
+	job.Idempotency = &entity.JobIdempotency{Key: "valid", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	valid, _ := repo.Add(job)
+
+	if len(keys.jobIDs) != 1 || keys.get("valid") != valid.ID || len(keys.expiring) != 1 {
+		t.Fatalf("expected only the valid idempotency key to be kept but got %v", keys.jobIDs)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_AddWithUniqueKey(t *testing.T) {
+	tests := []struct {
+		policy        string
+		schedule      string
+		expectedErr   error
+		expectedFirst string
+	}{
+		{entity.JobConflictReject, "1234567880", ErrUniqueKeyExists, entity.JobStatusPending},
+		{entity.JobConflictReplace, "1234567899", nil, entity.JobStatusCanceled},
+		{entity.JobConflictKeepEarliest, "1234567899", ErrUniqueKeyExists, entity.JobStatusPending},
+		{entity.JobConflictKeepEarliest, "1234567880", nil, entity.JobStatusCanceled},
+	}
+	for _, test := range tests {
+		repo, _ := New("in-memory")
+		job := aJob()
+		job.ClientKey = "foo"
+		job.UniqueKey = "order-123"
+		first, _ := repo.Add(job)
+
+		job.OnConflict = test.policy
+		job.Schedule.Value = test.schedule
+		second, err := repo.Add(job)
+		if err != test.expectedErr {
+			t.Fatalf("%s: expected error to be '%v' but got '%v'", test.policy, test.expectedErr, err)
+		}
+		if err != nil && second.ID != first.ID {
+			t.Fatalf("%s: expected existing job '%s' but got '%s'", test.policy, first.ID, second.ID)
+		}
+		assertStatus(t, repo, first.ID, test.expectedFirst)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_AddWithInvalidScheduleLeavesRepositoryUnchanged(t *testing.T) {
+	repo, _ := New("in-memory")
+	job := aJob()
+	job.ClientKey = "foo"
+	job.UniqueKey = "order-123"
+	first, _ := repo.Add(job)
+
+	job.OnConflict = entity.JobConflictReplace
+	job.Schedule.Value = "invalid"
+	job.Idempotency = &entity.JobIdempotency{Key: "key-1", Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	if _, err := repo.Add(job); err == nil {
+		t.Fatalf("expected error adding job with invalid schedule")
+	}
+	assertStatus(t, repo, first.ID, entity.JobStatusPending)
+	if n := repo.Count(JobFilter{}); n != 1 {
+		t.Fatalf("expected the invalid job not to be added but got %d jobs", n)
+	}
+
+	job.Schedule.Value = "1234567899"
+	second, err := repo.Add(job)
+	if err != nil || second.ReplacedID != first.ID {
+		t.Fatalf("expected retry to replace job '%s' but got %+v, error %v", first.ID, second, err)
+	}
+	if stored, _ := repo.Get(second.ID); stored.ReplacedID != "" {
+		t.Fatalf("expected replaced ID only on the returned job but got '%s'", stored.ReplacedID)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_AddWithUniqueKeyOfAnotherClient(t *testing.T) {
+	repo, _ := New("in-memory")
+	job := aJob()
+	job.UniqueKey = "order-123"
+	job.ClientKey = "foo"
+	repo.Add(job)
+	job.ClientKey = "bar"
+	if _, err := repo.Add(job); err != nil {
+		t.Fatalf("unable to add job: %v", err)
+	}
+}
+
 func Test_JobsInMemoryWithMutex_Get(t *testing.T) {
 	repo, _ := New("in-memory")
-	newJob, _ := repo.Add(entity.Job{})
+	newJob, _ := repo.Add(aJob())
 
 	job, _ := repo.Get(newJob.ID)
 	if job.ID != newJob.ID {
@@ -42,14 +181,15 @@ func Test_JobsInMemoryWithMutex_GetNonExistingJob(t *testing.T) {
 	repo, _ := New("in-memory")
 	job, _ := repo.Get("non-existing-job-id")
 	if job.ID != "" {
-		t.Fatalf("expected nil but got %s", job)
+		t.Fatalf("expected nil but got %v", job)
 	}
 }
 
 func Test_JobsInMemoryWithMutex_List(t *testing.T) {
 	repo, _ := New("in-memory")
 	var n = addJobs(repo, 5)
-	jobs, _ := repo.List(0, 10)
+	page, _ := repo.List(JobQuery{Limit: 10})
+	jobs := page.Jobs
 	if len(jobs) != n {
 		t.Fatalf("expected jobs list size to be %d but got %d", n, len(jobs))
 	}
@@ -58,12 +198,110 @@ func Test_JobsInMemoryWithMutex_List(t *testing.T) {
 func Test_JobsInMemoryWithMutex_ListWithPagination(t *testing.T) {
 	repo, _ := New("in-memory")
 	addJobs(repo, 10)
-	jobs, _ := repo.List(1, 5)
+	page, _ := repo.List(JobQuery{Skip: 1, Limit: 5})
+	jobs := page.Jobs
 	if len(jobs) != 5 {
 		t.Fatalf("expected jobs list size to be 5, but got %d", len(jobs))
 	}
 }
 
+func Test_JobsInMemoryWithMutex_ListWithFilter(t *testing.T) {
+	repo, _ := New("in-memory")
+	for i := 0; i < 6; i++ {
+		job := aJob()
+		job.ClientKey = strconv.Itoa(i % 2)
+		job.CallbackURL = fmt.Sprintf("http://host%d.example.com/callback", i%3)
+		repo.Add(job)
+	}
+	query := JobQuery{Filter: JobFilter{ClientKey: "1", CallbackHost: "host0.example.com"}, Limit: 10}
+	page, _ := repo.List(query)
+	jobs := page.Jobs
+	if len(jobs) != 1 {
+		t.Fatalf("expected jobs list size to be 1 but got %d", len(jobs))
+	}
+	if jobs[0].ClientKey != "1" || jobs[0].CallbackURL != "http://host0.example.com/callback" {
+		t.Fatalf("unexpected job in filtered list: %v", jobs[0])
+	}
+	if count := repo.Count(query.Filter); count != 1 {
+		t.Fatalf("expected filtered count to be 1 but got %d", count)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ListSortedByNextRun(t *testing.T) {
+	repo, _ := New("in-memory")
+	for _, timestamp := range []string{"1234567892", "1234567890", "1234567891"} {
+		job := aJob()
+		job.Schedule.Value = timestamp
+		repo.Add(job)
+	}
+	query := JobQuery{Filter: JobFilter{ScheduledBefore: 1234567892}, SortBy: SortByNextRun, SortOrder: SortDesc, Limit: 10}
+	page, _ := repo.List(query)
+	jobs := page.Jobs
+	if len(jobs) != 2 {
+		t.Fatalf("expected jobs list size to be 2 but got %d", len(jobs))
+	}
+	if jobs[0].Schedule.Value != "1234567891" || jobs[1].Schedule.Value != "1234567890" {
+		t.Fatalf("expected jobs in descending schedule order but got %s, %s", jobs[0].Schedule.Value, jobs[1].Schedule.Value)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ListScheduledRangeMatchesNextRun(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	repo.Reschedule(job.ID, 1234567990)
+
+	query := JobQuery{Filter: JobFilter{ScheduledAfter: 1234567900}, SortBy: SortByNextRun, Limit: 10}
+	if page, _ := repo.List(query); len(page.Jobs) != 1 || page.Jobs[0].NextRun != 1234567990 {
+		t.Fatalf("expected the rescheduled job to match its next run but got %+v", page.Jobs)
+	}
+	query.Filter = JobFilter{ScheduledBefore: 1234567900}
+	if page, _ := repo.List(query); len(page.Jobs) != 0 {
+		t.Fatalf("expected the rescheduled job not to match its original schedule but got %+v", page.Jobs)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ListWithCursor(t *testing.T) {
+	repo, _ := New("in-memory")
+	addJobs(repo, 5)
+	first, _ := repo.List(JobQuery{Limit: 2})
+	if first.NextCursor == "" {
+		t.Fatalf("expected first page to have a next cursor")
+	}
+
+	// removing a job from the first page must not shift the following pages
+	repo.Remove(first.Jobs[0].ID)
+	job := aJob()
+	job.ClientKey = "5"
+	repo.Add(job)
+
+	keys := make([]string, 0)
+	for cursor := first.NextCursor; cursor != ""; {
+		page, err := repo.List(JobQuery{Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("unable to list jobs: %v", err)
+		}
+		for _, job := range page.Jobs {
+			keys = append(keys, job.ClientKey)
+		}
+		cursor = page.NextCursor
+	}
+	if fmt.Sprint(keys) != "[2 3 4 5]" {
+		t.Fatalf("expected remaining pages to be [2 3 4 5] but got %v", keys)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ListWithInvalidCursor(t *testing.T) {
+	repo, _ := New("in-memory")
+	addJobs(repo, 3)
+	page, _ := repo.List(JobQuery{Limit: 1})
+	if _, err := repo.List(JobQuery{Cursor: page.NextCursor, SortBy: SortByNextRun, Limit: 1}); err == nil {
+		t.Fatalf("expected an error for a cursor used with different sort options")
+	}
+	if _, err := repo.List(JobQuery{Cursor: "foo", Limit: 1}); err == nil {
+		t.Fatalf("expected an error for a malformed cursor")
+	}
+}
+
 func Test_JobsInMemoryWithMutex_Remove(t *testing.T) {
 	repo, _ := New("in-memory")
 	initialSize := 5
@@ -85,8 +323,26 @@ func Test_JobsInMemoryWithMutex_Remove(t *testing.T) {
 	if job.ID != idToRemove {
 		t.Fatalf("expected removed job to have ID '%s' but got '%s'", idToRemove, job.ID)
 	}
-	if repo.Count() != expectedFinalSize {
-		t.Fatalf("expected repo final size to be %d but got %d", expectedFinalSize, repo.Count())
+	if repo.Count(JobFilter{}) != expectedFinalSize {
+		t.Fatalf("expected repo final size to be %d but got %d", expectedFinalSize, repo.Count(JobFilter{}))
+	}
+}
+
+func Test_JobsInMemoryWithMutex_Reschedule(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	if _, err := repo.Reschedule(job.ID, 1234567899); err != nil {
+		t.Fatalf("unable to reschedule job: %v", err)
+	}
+	if jobs, _ := repo.ListBySchedule(1234567890); len(jobs) != 0 {
+		t.Fatalf("expected no jobs at the original schedule but got %d", len(jobs))
+	}
+	jobs, _ := repo.ListBySchedule(1234567899)
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].NextRun != 1234567899 {
+		t.Fatalf("expected job to be rescheduled but got %v", jobs)
+	}
+	if jobs[0].Schedule.Value != "1234567890" {
+		t.Fatalf("expected job schedule not to change but got %s", jobs[0].Schedule.Value)
 	}
 }
 
@@ -100,6 +356,37 @@ func Test_JobsInMemoryWithMutex_Cancel(t *testing.T) {
 	assertStatus(t, repo, job.ID, entity.JobStatusCanceled)
 }
 
+func Test_JobsInMemoryWithMutex_CancelReleasesClaim(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567890, 0)
+	repo.ClaimDue(1234567890, "node-1", now, time.Second)
+
+	done := make(chan struct{})
+	go func() {
+		repo.ClaimDue(1234567890, "node-2", now.Add(time.Minute), time.Second)
+		close(done)
+	}()
+	canceled, err := repo.Cancel(job.ID)
+	<-done
+	if err != nil || canceled.Status != entity.JobStatusCanceled {
+		t.Fatalf("expected canceled job but got %+v, error %v", canceled, err)
+	}
+	if stored, _ := repo.Get(job.ID); stored.Claim != nil || stored.Status != entity.JobStatusCanceled {
+		t.Fatalf("expected canceled job without claim but got %+v", stored)
+	}
+	if jobs, _ := repo.ClaimDue(1234567890, "node-2", now.Add(time.Hour), time.Second); len(jobs) != 0 {
+		t.Fatalf("expected canceled job not to be claimed but got %+v", jobs)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_CancelNonExistingJob(t *testing.T) {
+	repo, _ := New("in-memory")
+	if _, err := repo.Cancel("non-existing-job-id"); err == nil {
+		t.Fatalf("expected an error canceling a non existing job")
+	}
+}
+
 func Test_JobsInMemoryWithMutex_AddExecution(t *testing.T) {
 	repo, _ := New("in-memory")
 	job, _ := repo.Add(aJob())
@@ -113,11 +400,203 @@ func Test_JobsInMemoryWithMutex_AddExecution(t *testing.T) {
 	}
 }
 
-func ExampleScheduler_List_ordering() {
+func Test_JobsInMemoryWithMutex_ListExecutions(t *testing.T) {
+	repo, _ := NewWithConfig("in-memory", Config{InlineExecutions: 2, ExecutionRetention: 5})
+	job, _ := repo.Add(aJob())
+	for i := 0; i < 7; i++ {
+		status := entity.JobStatusError
+		if i%2 == 0 {
+			status = entity.JobStatusSuccess
+		}
+		repo.AppendExecution(job.ID, entity.JobExecution{Timestamp: int64(i), Status: status})
+	}
+
+	updatedJob, _ := repo.Get(job.ID)
+	if len(updatedJob.Executions) != 2 || updatedJob.Executions[1].Timestamp != 6 {
+		t.Fatalf("expected the 2 latest executions inline but got %v", updatedJob.Executions)
+	}
+
+	page, err := repo.ListExecutions(ExecutionQuery{JobID: job.ID, Limit: 3})
+	if err != nil {
+		t.Fatalf("unable to list executions: %v", err)
+	}
+	if len(page.Executions) != 3 || page.Executions[0].Timestamp != 6 || page.NextCursor == "" {
+		t.Fatalf("expected first page with the 3 newest executions but got %v", page.Executions)
+	}
+	page, _ = repo.ListExecutions(ExecutionQuery{JobID: job.ID, Limit: 3, Cursor: page.NextCursor})
+	if len(page.Executions) != 2 || page.Executions[1].Timestamp != 2 || page.NextCursor != "" {
+		t.Fatalf("expected last page with the 2 oldest retained executions but got %v", page.Executions)
+	}
+
+	page, _ = repo.ListExecutions(ExecutionQuery{JobID: job.ID, Status: entity.JobStatusError})
+	if len(page.Executions) != 2 || page.Executions[0].Timestamp != 5 {
+		t.Fatalf("expected the 2 retained failed executions but got %v", page.Executions)
+	}
+	if _, err := repo.ListExecutions(ExecutionQuery{JobID: "non-existing-job-id"}); err == nil {
+		t.Fatalf("expected an error listing executions of a non existing job")
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ClaimDue(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567890, 0)
+
+	claimed, _ := repo.ClaimDue(1234567890, "node-1", now, time.Minute)
+	if len(claimed) != 1 || claimed[0].Claim.Owner != "node-1" {
+		t.Fatalf("expected job to be claimed by node-1 but got %v", claimed)
+	}
+	if claimed, _ := repo.ClaimDue(1234567890, "node-2", now.Add(time.Second), time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected claimed job not to be claimed again but got %v", claimed)
+	}
+	if _, err := repo.Heartbeat(job.ID, "node-1", now.Add(30*time.Second), time.Minute); err != nil {
+		t.Fatalf("unable to renew job claim: %v", err)
+	}
+	if claimed, _ := repo.ClaimDue(1234567950, "node-2", now.Add(time.Minute), time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected renewed claim not to be taken over but got %v", claimed)
+	}
+
+	reclaimed, _ := repo.ClaimDue(1234567980, "node-2", now.Add(90*time.Second), time.Minute)
+	if len(reclaimed) != 1 || reclaimed[0].Claim.Owner != "node-2" {
+		t.Fatalf("expected expired claim to be taken over by node-2 but got %v", reclaimed)
+	}
+	if reclaimed[0].Claim.ExecutionID != claimed[0].Claim.ExecutionID {
+		t.Fatalf("expected execution ID %s to be kept but got %s", claimed[0].Claim.ExecutionID, reclaimed[0].Claim.ExecutionID)
+	}
+	if claimed[0].Claim.Attempt != 1 || reclaimed[0].Claim.Attempt != 2 {
+		t.Fatalf("expected claims to be attempts 1 and 2 but got %d and %d", claimed[0].Claim.Attempt, reclaimed[0].Claim.Attempt)
+	}
+	if _, err := repo.Complete(job.ID, "node-1", time.Now(), entity.JobStatusSuccess, ""); err != ErrClaimLost {
+		t.Fatalf("expected previous owner to have lost the claim but got %v", err)
+	}
+	if _, err := repo.Complete(job.ID, "node-2", time.Now(), entity.JobStatusSuccess, ""); err != nil {
+		t.Fatalf("unable to complete claimed job: %v", err)
+	}
+	assertStatus(t, repo, job.ID, entity.JobStatusSuccess)
+	if claimed, _ := repo.ClaimDue(1234568100, "node-1", now.Add(time.Hour), time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected completed job not to be claimed again but got %v", claimed)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_RescheduleReleasesClaim(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567890, 0)
+	repo.ClaimDue(1234567890, "node-1", now, time.Minute)
+
+	if _, err := repo.Reschedule(job.ID, 1234567891); err != nil {
+		t.Fatalf("unable to reschedule job: %v", err)
+	}
+	if _, err := repo.Heartbeat(job.ID, "node-1", now, time.Minute); err != ErrClaimLost {
+		t.Fatalf("expected rescheduled job claim to be released but got %v", err)
+	}
+	claimed, _ := repo.ClaimDue(1234567891, "node-2", now.Add(time.Second), time.Minute)
+	if len(claimed) != 1 || claimed[0].Claim.ExecutionID != fmt.Sprintf("%s-1234567891", job.ID) {
+		t.Fatalf("expected rescheduled job to be claimed with a new execution ID but got %v", claimed)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ClaimDueRescheduledToClaimedTimestamp(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567890, 0)
+	repo.ClaimDue(1234567890, "node-1", now, time.Minute)
+	repo.ClaimDue(1234567891, "node-1", now.Add(time.Second), time.Minute)
+
+	// deferred to a second already claimed, as a callback deferred at the end of it
+	if _, err := repo.Reschedule(job.ID, 1234567891); err != nil {
+		t.Fatalf("unable to reschedule job: %v", err)
+	}
+	claimed, _ := repo.ClaimDue(1234567892, "node-1", now.Add(2*time.Second), time.Minute)
+	if len(claimed) != 1 || claimed[0].ID != job.ID {
+		t.Fatalf("expected job rescheduled to a claimed timestamp to be claimed with the next one but got %v", claimed)
+	}
+	if claimed, _ := repo.ClaimDue(1234567893, "node-1", now.Add(3*time.Second), time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected overdue job to be claimed once but got %v", claimed)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_PauseAndResume(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567800, 0)
+	repo.ClaimDue(1234567890, "node-1", now, time.Minute)
+
+	if _, err := repo.Pause(job.ID, now); err != nil {
+		t.Fatalf("unable to pause job: %v", err)
+	}
+	assertStatus(t, repo, job.ID, entity.JobStatusPaused)
+	if _, err := repo.Heartbeat(job.ID, "node-1", now, time.Minute); err != ErrClaimLost {
+		t.Fatalf("expected paused job claim to be released but got %v", err)
+	}
+	if claimed, _ := repo.ClaimDue(1234567890, "node-1", now, time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected paused job not to be claimed but got %v", claimed)
+	}
+
+	resumed, err := repo.Resume(job.ID, entity.JobMissedRunOnce, now)
+	if err != nil {
+		t.Fatalf("unable to resume job: %v", err)
+	}
+	if resumed.Status != entity.JobStatusPending || resumed.NextRun != 1234567890 {
+		t.Fatalf("expected resumed job to keep its schedule but got status '%s' and next run %d", resumed.Status, resumed.NextRun)
+	}
+	if _, err := repo.Resume(job.ID, entity.JobMissedRunOnce, now); err == nil {
+		t.Fatalf("expected an error resuming a pending job")
+	}
+}
+
+func Test_JobsInMemoryWithMutex_CompletePausedWhileRunning(t *testing.T) {
+	repo, _ := New("in-memory")
+	job, _ := repo.Add(aJob())
+	now := time.Unix(1234567890, 0)
+	repo.ClaimDue(1234567890, "node-1", now, time.Minute)
+	if _, err := repo.Heartbeat(job.ID, "node-1", now, time.Minute); err != nil {
+		t.Fatalf("unable to renew job claim: %v", err)
+	}
+
+	// paused while its callback is being sent
+	repo.Pause(job.ID, now)
+	completed, err := repo.Complete(job.ID, "node-1", now.Add(time.Second), entity.JobStatusSuccess, "")
+	if err != nil {
+		t.Fatalf("unable to complete job paused while running: %v", err)
+	}
+	if completed.Status != entity.JobStatusPaused || len(completed.Executions) != 1 || completed.Executions[0].Status != entity.JobStatusSuccess {
+		t.Fatalf("expected paused job with the execution added but got %+v", completed)
+	}
+}
+
+func Test_JobsInMemoryWithMutex_ResumeMissedRun(t *testing.T) {
+	repo, _ := New("in-memory")
+	now := time.Unix(1234567900, 0)
+	for _, missed := range []string{entity.JobMissedRunOnce, entity.JobMissedRunAll} {
+		job, _ := repo.Add(aJob())
+		repo.Pause(job.ID, now)
+		resumed, err := repo.Resume(job.ID, missed, now)
+		if err != nil {
+			t.Fatalf("unable to resume job: %v", err)
+		}
+		if resumed.Status != entity.JobStatusPending || resumed.NextRun != now.Unix()+1 {
+			t.Fatalf("expected missed run to be rescheduled with '%s' but got status '%s' and next run %d", missed, resumed.Status, resumed.NextRun)
+		}
+	}
+
+	job, _ := repo.Add(aJob())
+	repo.Pause(job.ID, now)
+	if _, err := repo.Resume(job.ID, "later", now); err == nil {
+		t.Fatalf("expected an error resuming with an invalid missed policy")
+	}
+	resumed, _ := repo.Resume(job.ID, entity.JobMissedSkip, now)
+	if resumed.Status != entity.JobStatusSkipped || len(resumed.Executions) != 1 {
+		t.Fatalf("expected missed run to be skipped but got status '%s' and %d executions", resumed.Status, len(resumed.Executions))
+	}
+}
+
+func ExampleJobsInMemoryWithMutex_List_ordering() {
 	repo, _ := New("in-memory")
 	n := 10
 	addJobs(repo, n)
-	jobs, _ := repo.List(0, n)
+	page, _ := repo.List(JobQuery{Limit: n})
+	jobs := page.Jobs
 	keys := make([]string, n)
 	for j := range jobs {
 		keys[j] = jobs[j].ClientKey
@@ -129,7 +608,9 @@ func ExampleScheduler_List_ordering() {
 
 func addJobs(repo Jobs, n int) int {
 	for i := 0; i < n; i++ {
-		repo.Add(entity.Job{ClientKey: strconv.Itoa(i)})
+		job := aJob()
+		job.ClientKey = strconv.Itoa(i)
+		repo.Add(job)
 	}
 	return n
 }
@@ -21,14 +21,19 @@ func (r *JobsMySQL) Add(entity.Job) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// AddAll ...
+func (r *JobsMySQL) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	return make([]entity.Job, len(jobs)), make([]error, len(jobs))
+}
+
 // Get ...
 func (r *JobsMySQL) Get(id string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // List ...
-func (r *JobsMySQL) List(skip int, limit int) ([]entity.Job, error) {
-	return make([]entity.Job, 0), nil
+func (r *JobsMySQL) List(query JobQuery) (JobPage, error) {
+	return JobPage{Jobs: make([]entity.Job, 0)}, nil
 }
 
 // Remove ...
@@ -41,13 +46,18 @@ func (r *JobsMySQL) Cancel(jobID string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// Reschedule ...
+func (r *JobsMySQL) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
 // AddExecution ...
 func (r *JobsMySQL) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // Count ...
-func (r *JobsMySQL) Count() int {
+func (r *JobsMySQL) Count(filter JobFilter) int {
 	return 0
 }
 
@@ -55,3 +65,43 @@ func (r *JobsMySQL) Count() int {
 func (r *JobsMySQL) ListBySchedule(timestamp int64) ([]entity.Job, error) {
 	return make([]entity.Job, 0), nil
 }
+
+// ClaimDue ...
+func (r *JobsMySQL) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	return make([]entity.Job, 0), nil
+}
+
+// Heartbeat ...
+func (r *JobsMySQL) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Complete ...
+func (r *JobsMySQL) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Pause ...
+func (r *JobsMySQL) Pause(jobID string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Resume ...
+func (r *JobsMySQL) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// AppendExecution ...
+func (r *JobsMySQL) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// ListExecutions ...
+func (r *JobsMySQL) ListExecutions(query ExecutionQuery) (ExecutionPage, error) {
+	return ExecutionPage{Executions: make([]entity.JobExecution, 0)}, nil
+}
+
+// Ping ...
+func (r *JobsMySQL) Ping() error {
+	return nil
+}
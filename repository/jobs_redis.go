@@ -21,14 +21,19 @@ func (r *JobsRedis) Add(entity.Job) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// AddAll ...
+func (r *JobsRedis) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	return make([]entity.Job, len(jobs)), make([]error, len(jobs))
+}
+
 // Get ...
 func (r *JobsRedis) Get(id string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // List ...
-func (r *JobsRedis) List(skip int, limit int) ([]entity.Job, error) {
-	return make([]entity.Job, 0), nil
+func (r *JobsRedis) List(query JobQuery) (JobPage, error) {
+	return JobPage{Jobs: make([]entity.Job, 0)}, nil
 }
 
 // Remove ...
@@ -41,13 +46,18 @@ func (r *JobsRedis) Cancel(jobID string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// Reschedule ...
+func (r *JobsRedis) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
 // AddExecution ...
 func (r *JobsRedis) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // Count ...
-func (r *JobsRedis) Count() int {
+func (r *JobsRedis) Count(filter JobFilter) int {
 	return 0
 }
 
@@ -55,3 +65,43 @@ func (r *JobsRedis) Count() int {
 func (r *JobsRedis) ListBySchedule(timestamp int64) ([]entity.Job, error) {
 	return make([]entity.Job, 0), nil
 }
+
+// ClaimDue ...
+func (r *JobsRedis) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	return make([]entity.Job, 0), nil
+}
+
+// Heartbeat ...
+func (r *JobsRedis) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Complete ...
+func (r *JobsRedis) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Pause ...
+func (r *JobsRedis) Pause(jobID string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Resume ...
+func (r *JobsRedis) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// AppendExecution ...
+func (r *JobsRedis) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// ListExecutions ...
+func (r *JobsRedis) ListExecutions(query ExecutionQuery) (ExecutionPage, error) {
+	return ExecutionPage{Executions: make([]entity.JobExecution, 0)}, nil
+}
+
+// Ping ...
+func (r *JobsRedis) Ping() error {
+	return nil
+}
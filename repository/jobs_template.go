@@ -21,14 +21,19 @@ func (r *JobsTemplate) Add(entity.Job) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// AddAll ...
+func (r *JobsTemplate) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	return make([]entity.Job, len(jobs)), make([]error, len(jobs))
+}
+
 // Get ...
 func (r *JobsTemplate) Get(id string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // List ...
-func (r *JobsTemplate) List(skip int, limit int) ([]entity.Job, error) {
-	return make([]entity.Job, 0), nil
+func (r *JobsTemplate) List(query JobQuery) (JobPage, error) {
+	return JobPage{Jobs: make([]entity.Job, 0)}, nil
 }
 
 // Remove ...
@@ -41,13 +46,18 @@ func (r *JobsTemplate) Cancel(jobID string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
+// Reschedule ...
+func (r *JobsTemplate) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
 // AddExecution ...
 func (r *JobsTemplate) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
 	return entity.Job{}, nil
 }
 
 // Count ...
-func (r *JobsTemplate) Count() int {
+func (r *JobsTemplate) Count(filter JobFilter) int {
 	return 0
 }
 
@@ -55,3 +65,43 @@ func (r *JobsTemplate) Count() int {
 func (r *JobsTemplate) ListBySchedule(timestamp int64) ([]entity.Job, error) {
 	return make([]entity.Job, 0), nil
 }
+
+// ClaimDue ...
+func (r *JobsTemplate) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	return make([]entity.Job, 0), nil
+}
+
+// Heartbeat ...
+func (r *JobsTemplate) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Complete ...
+func (r *JobsTemplate) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Pause ...
+func (r *JobsTemplate) Pause(jobID string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// Resume ...
+func (r *JobsTemplate) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// AppendExecution ...
+func (r *JobsTemplate) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	return entity.Job{}, nil
+}
+
+// ListExecutions ...
+func (r *JobsTemplate) ListExecutions(query ExecutionQuery) (ExecutionPage, error) {
+	return ExecutionPage{Executions: make([]entity.JobExecution, 0)}, nil
+}
+
+// Ping ...
+func (r *JobsTemplate) Ping() error {
+	return nil
+}
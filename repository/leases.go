@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// ErrLeaseHeld is returned when a lease is held by another holder. The current lease is returned
+// along with it.
+var ErrLeaseHeld = errors.New("lease is held by another holder")
+
+// Leases is a repository of leases used to coordinate multiple Schedula nodes. Implementations
+// must apply each operation atomically, as nodes compete for the same leases.
+type Leases interface {
+	// Acquire grants the lease to the holder for ttl if it's free, expired or already held by
+	// it, in which case it's renewed.
+	Acquire(name string, holder string, ttl time.Duration, now time.Time) (entity.Lease, error)
+	// Checkpoint advances the lease checkpoint, as long as the holder still holds it.
+	Checkpoint(name string, holder string, checkpoint int64, now time.Time) (entity.Lease, error)
+	// Release frees the lease if it's held by the holder, keeping its checkpoint.
+	Release(name string, holder string) error
+	Get(name string) (entity.Lease, error)
+}
+
+// NewLeases creates a leases repository of the given type.
+func NewLeases(repoType string) (Leases, error) {
+	switch repoType {
+	case "in-memory", "in-memory-mutex":
+		return NewLeasesInMemory()
+	case "redis", "mysql":
+		// nodes only coordinate through leases stored in the repository they share
+		return nil, fmt.Errorf("leases not supported by repository type '%s'", repoType)
+	}
+	return nil, fmt.Errorf("invalid leases repository type: '%s'", repoType)
+}
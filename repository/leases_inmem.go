@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// LeasesInMemory keeps leases in memory, so it only coordinates schedulers of the same process.
+type LeasesInMemory struct {
+	sync.Mutex
+	leases map[string]*entity.Lease
+}
+
+// NewLeasesInMemory ...
+func NewLeasesInMemory() (Leases, error) {
+	return &LeasesInMemory{leases: make(map[string]*entity.Lease)}, nil
+}
+
+// Acquire ...
+func (r *LeasesInMemory) Acquire(name string, holder string, ttl time.Duration, now time.Time) (entity.Lease, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	lease := r.leases[name]
+	if lease == nil {
+		lease = &entity.Lease{Name: name}
+		r.leases[name] = lease
+	}
+	if lease.Holder != holder && !lease.IsExpired(now) {
+		return *lease, ErrLeaseHeld
+	}
+	lease.Holder = holder
+	lease.ExpiresAt = now.Add(ttl)
+	return *lease, nil
+}
+
+// Checkpoint ...
+func (r *LeasesInMemory) Checkpoint(name string, holder string, checkpoint int64, now time.Time) (entity.Lease, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	lease := r.leases[name]
+	if lease == nil {
+		return entity.Lease{}, fmt.Errorf("lease '%s' not found", name)
+	}
+	if !lease.IsHeldBy(holder, now) {
+		return *lease, ErrLeaseHeld
+	}
+	if checkpoint <= lease.Checkpoint {
+		return *lease, fmt.Errorf("lease '%s' checkpoint %d is not after %d", name, checkpoint, lease.Checkpoint)
+	}
+	lease.Checkpoint = checkpoint
+	return *lease, nil
+}
+
+// Release ...
+func (r *LeasesInMemory) Release(name string, holder string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	lease := r.leases[name]
+	if lease == nil || lease.Holder != holder {
+		return ErrLeaseHeld
+	}
+	lease.Holder = ""
+	lease.ExpiresAt = time.Time{}
+	return nil
+}
+
+// Get ...
+func (r *LeasesInMemory) Get(name string) (entity.Lease, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	lease := r.leases[name]
+	if lease == nil {
+		return entity.Lease{}, fmt.Errorf("lease '%s' not found", name)
+	}
+	return *lease, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_LeasesInMemory_Acquire(t *testing.T) {
+	repo, _ := NewLeases("in-memory")
+	now := time.Unix(1000, 0)
+	if _, err := repo.Acquire("scheduler", "node-1", 5*time.Second, now); err != nil {
+		t.Fatalf("unable to acquire lease: %v", err)
+	}
+	if lease, err := repo.Acquire("scheduler", "node-2", 5*time.Second, now.Add(4*time.Second)); err != ErrLeaseHeld || lease.Holder != "node-1" {
+		t.Fatalf("expected lease held by node-1 but got '%s' (%v)", lease.Holder, err)
+	}
+	if _, err := repo.Acquire("scheduler", "node-1", 5*time.Second, now.Add(4*time.Second)); err != nil {
+		t.Fatalf("unable to renew lease: %v", err)
+	}
+	if _, err := repo.Acquire("scheduler", "node-2", 5*time.Second, now.Add(8*time.Second)); err != ErrLeaseHeld {
+		t.Fatalf("expected renewed lease to be held by node-1 but got %v", err)
+	}
+	if lease, err := repo.Acquire("scheduler", "node-2", 5*time.Second, now.Add(9*time.Second)); err != nil || lease.Holder != "node-2" {
+		t.Fatalf("expected expired lease to be acquired by node-2 but got '%s' (%v)", lease.Holder, err)
+	}
+}
+
+func Test_LeasesInMemory_Checkpoint(t *testing.T) {
+	repo, _ := NewLeases("in-memory")
+	now := time.Unix(1000, 0)
+	repo.Acquire("scheduler", "node-1", 5*time.Second, now)
+	if _, err := repo.Checkpoint("scheduler", "node-1", 1000, now); err != nil {
+		t.Fatalf("unable to advance lease checkpoint: %v", err)
+	}
+	if _, err := repo.Checkpoint("scheduler", "node-1", 1000, now); err == nil {
+		t.Fatalf("expected an error moving the checkpoint backwards")
+	}
+	if _, err := repo.Checkpoint("scheduler", "node-2", 1001, now); err != ErrLeaseHeld {
+		t.Fatalf("expected an error advancing the checkpoint of a lease held by another node but got %v", err)
+	}
+	if _, err := repo.Checkpoint("scheduler", "node-1", 1001, now.Add(5*time.Second)); err != ErrLeaseHeld {
+		t.Fatalf("expected an error advancing the checkpoint of an expired lease but got %v", err)
+	}
+
+	if err := repo.Release("scheduler", "node-1"); err != nil {
+		t.Fatalf("unable to release lease: %v", err)
+	}
+	lease, err := repo.Acquire("scheduler", "node-2", 5*time.Second, now.Add(time.Second))
+	if err != nil || lease.Checkpoint != 1000 {
+		t.Fatalf("expected released lease to be acquired with checkpoint 1000 but got %d (%v)", lease.Checkpoint, err)
+	}
+}
+
+func Test_CheckType(t *testing.T) {
+	for _, repoType := range []string{"in-memory", "in-memory-mutex"} {
+		if err := CheckType(repoType); err != nil {
+			t.Fatalf("expected repository type '%s' to hold every store but got %v", repoType, err)
+		}
+		if _, err := NewLeases(repoType); err != nil {
+			t.Fatalf("unable to create leases repository of type '%s': %v", repoType, err)
+		}
+		if _, err := NewPauses(repoType); err != nil {
+			t.Fatalf("unable to create pauses repository of type '%s': %v", repoType, err)
+		}
+		if _, err := NewAPIKeys(repoType); err != nil {
+			t.Fatalf("unable to create API keys repository of type '%s': %v", repoType, err)
+		}
+	}
+	for _, repoType := range []string{"redis", "mysql", "in-memory-ch", "unknown"} {
+		if err := CheckType(repoType); err == nil {
+			t.Fatalf("expected an error checking repository type '%s'", repoType)
+		}
+	}
+}
+
+func Test_NewLeases_UnsupportedRepositoryTypes(t *testing.T) {
+	for _, repoType := range []string{"redis", "mysql", "in-memory-ch", "unknown"} {
+		if _, err := NewLeases(repoType); err == nil {
+			t.Fatalf("expected error creating leases repository of type '%s'", repoType)
+		}
+	}
+}
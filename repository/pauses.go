@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// Pauses is a repository of the pauses holding the jobs of a client or callback host
+type Pauses interface {
+	// Add keeps the pause, replacing the one of the same client and host if any.
+	Add(entity.Pause) (entity.Pause, error)
+	// Remove lifts the pause of the given client and host, returning whether there was one.
+	Remove(clientKey string, callbackHost string) (bool, error)
+	List() ([]entity.Pause, error)
+}
+
+// NewPauses creates a pauses repository of the given type.
+func NewPauses(repoType string) (Pauses, error) {
+	switch repoType {
+	case "in-memory", "in-memory-mutex":
+		return NewPausesInMemory()
+	}
+	return nil, fmt.Errorf("invalid pauses repository type: '%s'", repoType)
+}
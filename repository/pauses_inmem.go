@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+// PausesInMemory keeps the pauses in memory
+type PausesInMemory struct {
+	sync.RWMutex
+	pauses map[string]entity.Pause
+}
+
+// NewPausesInMemory ...
+func NewPausesInMemory() (Pauses, error) {
+	return &PausesInMemory{pauses: make(map[string]entity.Pause)}, nil
+}
+
+// Add ...
+func (r *PausesInMemory) Add(pause entity.Pause) (entity.Pause, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.pauses[pause.Key()] = pause
+	return pause, nil
+}
+
+// Remove ...
+func (r *PausesInMemory) Remove(clientKey string, callbackHost string) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+	key := (&entity.Pause{ClientKey: clientKey, CallbackHost: callbackHost}).Key()
+	_, exists := r.pauses[key]
+	delete(r.pauses, key)
+	return exists, nil
+}
+
+// List returns the pauses sorted by client and host
+func (r *PausesInMemory) List() ([]entity.Pause, error) {
+	r.RLock()
+	defer r.RUnlock()
+	res := make([]entity.Pause, 0, len(r.pauses))
+	for _, pause := range r.pauses {
+		res = append(res, pause)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key() < res[j].Key() })
+	return res, nil
+}
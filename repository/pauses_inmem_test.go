@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+func Test_PausesInMemory(t *testing.T) {
+	repo, _ := NewPauses("in-memory")
+	repo.Add(entity.Pause{ClientKey: "acme", PausedAt: 1})
+	repo.Add(entity.Pause{CallbackHost: "Example.com", PausedAt: 2})
+	repo.Add(entity.Pause{ClientKey: "acme", PausedAt: 3})
+
+	pauses, _ := repo.List()
+	if len(pauses) != 2 || pauses[0].ClientKey != "" || pauses[1].PausedAt != 3 {
+		t.Fatalf("expected the pauses of acme and example.com but got %+v", pauses)
+	}
+	job := &entity.Job{ClientKey: "other", CallbackURL: "http://example.com/callback"}
+	if !pauses[0].Matches(job) || pauses[1].Matches(job) {
+		t.Fatalf("expected only the host pause to match the job")
+	}
+
+	if removed, _ := repo.Remove("", "example.com"); !removed {
+		t.Fatalf("expected host pause to be removed")
+	}
+	if removed, _ := repo.Remove("", "example.com"); removed {
+		t.Fatalf("expected no pause left to remove")
+	}
+	if pauses, _ := repo.List(); len(pauses) != 1 {
+		t.Fatalf("expected 1 pause left but got %+v", pauses)
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/marcoshack/schedula/entity"
+)
+
+const (
+	// SortByCreatedAt orders jobs by their creation time
+	SortByCreatedAt = "createdAt"
+
+	// SortByNextRun orders jobs by the next time they are scheduled to run
+	SortByNextRun = "nextRun"
+
+	// SortAsc ...
+	SortAsc = "asc"
+
+	// SortDesc ...
+	SortDesc = "desc"
+)
+
+// JobFilter restricts the set of jobs returned by a query. Zero values match any job.
+// Time ranges are epoch timestamps, 'After' bounds are inclusive and 'Before' bounds are exclusive.
+type JobFilter struct {
+	Status          string
+	ClientKey       string
+	CallbackHost    string
+	ScheduledAfter  int64
+	ScheduledBefore int64
+	CreatedAfter    int64
+	CreatedBefore   int64
+	// UniqueKey restricts the jobs to the ones with the given unique key, indexed along with the
+	// ClientKey
+	UniqueKey string
+	// Unfinished restricts the jobs to the ones yet to run, either pending or paused
+	Unfinished bool
+}
+
+// Match returns whether the given job satisfies all the filter conditions
+func (f *JobFilter) Match(job *entity.Job) bool {
+	if f.Status != "" && job.Status != f.Status {
+		return false
+	}
+	if f.Unfinished && job.Status != entity.JobStatusPending && job.Status != entity.JobStatusPaused {
+		return false
+	}
+	if f.ClientKey != "" && job.ClientKey != f.ClientKey {
+		return false
+	}
+	if f.UniqueKey != "" && job.UniqueKey != f.UniqueKey {
+		return false
+	}
+	if f.CallbackHost != "" && !strings.EqualFold(callbackHost(job), f.CallbackHost) {
+		return false
+	}
+	// the scheduled range is matched against the next run, the same value the jobs are sorted by
+	if (f.ScheduledAfter != 0 || f.ScheduledBefore != 0) && !inRange(job.NextRun, f.ScheduledAfter, f.ScheduledBefore) {
+		return false
+	}
+	return inRange(job.CreatedAt, f.CreatedAfter, f.CreatedBefore)
+}
+
+// JobQuery describes which jobs to list and in which order. The zero value lists
+// jobs in insertion order. Cursor is the NextCursor of a previous page of the same
+// query, when given the listing resumes right after the last job of that page.
+type JobQuery struct {
+	Filter    JobFilter
+	SortBy    string
+	SortOrder string
+	Cursor    string
+	Skip      int
+	Limit     int
+}
+
+// JobPage is a page of a job listing. NextCursor is blank when there are no more jobs to list.
+type JobPage struct {
+	Jobs       []entity.Job
+	NextCursor string
+}
+
+// Validate checks the sort options and the cursor of the query
+func (q *JobQuery) Validate() error {
+	switch q.SortBy {
+	case "", SortByCreatedAt, SortByNextRun:
+	default:
+		return fmt.Errorf("invalid sort field: '%s'", q.SortBy)
+	}
+	switch q.SortOrder {
+	case "", SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("invalid sort order: '%s'", q.SortOrder)
+	}
+	if q.Cursor != "" {
+		if _, err := parseCursor(q.Cursor, q.SortBy, q.SortOrder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inRange(value int64, from int64, to int64) bool {
+	if from != 0 && value < from {
+		return false
+	}
+	if to != 0 && value >= to {
+		return false
+	}
+	return true
+}
+
+func callbackHost(job *entity.Job) string {
+	u, err := url.Parse(job.CallbackURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// jobEntry pairs a job with its insertion sequence number, used to break sort ties and to
+// position cursors
+type jobEntry struct {
+	job *entity.Job
+	seq uint64
+}
+
+// sortKey returns the value a job is ordered by for the given sort field
+func sortKey(job *entity.Job, sortBy string) int64 {
+	switch sortBy {
+	case SortByCreatedAt:
+		return job.CreatedAt
+	case SortByNextRun:
+		return job.NextRun
+	}
+	return 0
+}
+
+// sortJobs sorts the given entries in place. Insertion order is used to break ties and when no
+// sort field is given.
+func sortJobs(entries []jobEntry, sortBy string, order string) {
+	sort.Slice(entries, func(i, j int) bool {
+		ki, kj := sortKey(entries[i].job, sortBy), sortKey(entries[j].job, sortBy)
+		if order == SortDesc {
+			return ki > kj || (ki == kj && entries[i].seq > entries[j].seq)
+		}
+		return ki < kj || (ki == kj && entries[i].seq < entries[j].seq)
+	})
+}
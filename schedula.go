@@ -1,101 +1,483 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
 	"github.com/marcoshack/schedula/handler"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/metrics"
+	"github.com/marcoshack/schedula/quota"
 	"github.com/marcoshack/schedula/repository"
 	"github.com/marcoshack/schedula/scheduler"
+	"github.com/marcoshack/schedula/tracing"
 )
 
 const version = "0.1"
 
+var logger = logging.Component("schedula")
+
 var (
 	bindAddr  = flag.String("b", "0.0.0.0", "IP `address` to bind")
 	bindPort  = flag.Int("p", 8080, "TCP `port` number to bind")
 	nWorkers  = flag.Int("w", 5, "number of `workers` to execute callbacks for each host")
-	repoType  = flag.String("repo-type", "in-memory", "Repository `type`: in-memory, in-memory-ch, redis, mysql")
+	hostQueue = flag.Int("host-queue-size", scheduler.DefaultHostQueueSize, "`number` of callbacks queued for each host, the overflow is deferred to the next second")
+	hostIdle  = flag.String("host-idle-timeout", "10m", "time `duration` after which the workers of a host without callbacks are stopped, 0 to keep them")
+	repoType  = flag.String("repo-type", "in-memory", "Repository `type`: in-memory. The redis and mysql types don't hold job claims, pauses, API keys and leases yet")
+	inlineExe = flag.Int("inline-executions", repository.DefaultInlineExecutions, "`number` of latest executions returned with each job, the full history is at /jobs/{id}/executions")
+	keepExe   = flag.Int("execution-retention", repository.DefaultExecutionRetention, "`number` of executions kept in the history of each job")
 	schedType = flag.String("sched-type", "ticker", "Scheduler `type`: ticker")
+	nodeID    = flag.String("node-id", "", "`name` identifying this node among the ones sharing the repository, defaults to hostname and PID")
+	claimTTL  = flag.String("claim-ttl", "30s", "time `duration` of the claim on a published job, renewed while its callback runs. Callbacks of expired claims are retried")
+	leaseTTL  = flag.String("lease-ttl", "0", "time `duration` of the scheduler leader lease, coordinating the nodes sharing the repository. 0 runs standalone")
 	timeout   = flag.String("timeout", "5s", "time `duration` to timeout callback requests")
+	grace     = flag.String("shutdown-grace", "30s", "time `duration` to wait for in-flight requests and callbacks on shutdown")
+	idemWin   = flag.String("idempotency-window", "24h", "time `duration` to keep job idempotency keys, 0 to disable them")
+	idemScope = flag.String("idempotency-scope", "client", "idempotency keys `scope`: client, global")
+	auth      = flag.Bool("auth", false, "require API keys and restrict clients to their own jobs")
+	adminKey  = flag.String("admin-key", "", "admin API `key` to bootstrap authentication, a random one is issued if blank")
+	maxPend   = flag.Int("max-pending", 0, "default maximum `number` of pending jobs per client, 0 for unlimited")
+	crtRate   = flag.Float64("create-rate", 0, "default job creation `rate` per second per client, 0 for unlimited")
+	crtBurst  = flag.Int("create-burst", 10, "default job creation burst `size` per client")
+	maxData   = flag.Int("max-data-size", 0, "default maximum job data size in `bytes`, 0 for unlimited")
+	hostRate  = flag.Float64("host-rate", 0, "default callback `rate` per second for each host, 0 for unlimited")
+	hostBurst = flag.Int("host-burst", 1, "default callback burst `size` for each host")
+	hostConc  = flag.Int("host-max-inflight", 0, "default maximum `number` of concurrent callbacks for each host, 0 for unlimited")
+	allowHost = flag.String("callback-allow", "", "comma separated host `patterns` callbacks are restricted to, e.g. '*.example.com,localhost:*'. Blank allows every host")
+	denyHost  = flag.String("callback-deny", "", "comma separated host `patterns` callbacks are never sent to, even if allowed")
+	hostRules = flag.String("host-limits", "", "callback limits `rules` overriding the defaults for matching hosts, e.g. '*.example.com=10:20:5' (rate:burst:maxInFlight)")
+	brkWindow = flag.Int("breaker-window", 20, "`number` of recent callbacks to a host considered by its circuit breaker, 0 to disable it")
+	brkMinReq = flag.Int("breaker-min-requests", 10, "minimum `number` of recent callbacks to a host before its circuit breaker can open")
+	brkRate   = flag.Float64("breaker-failure-rate", 0.5, "failure `rate` (0 to 1) of recent callbacks to a host that opens its circuit breaker")
+	brkOpen   = flag.String("breaker-open", "30s", "time `duration` a host circuit breaker stays open before probing the host again")
+	logLevel  = flag.String("log-level", "info", "minimum `level` of the log records: debug, info, warn, error")
+	logFormat = flag.String("log-format", "text", "log records `format`: text, json")
+	readyTick = flag.String("ready-tick-tolerance", "5s", "time `duration` the scheduler loop can go without ticking before /readyz fails")
+	evHistory = flag.Int("event-history", events.DefaultHistorySize, "`number` of latest job events kept for /events streams to resume from")
+	traceExp  = flag.String("trace-exporter", "", "`type` of the exporter of the job creation, dispatch and callback traces: stdout, otlp. Blank disables tracing")
+	traceEnd  = flag.String("trace-endpoint", tracing.DefaultOTLPEndpoint, "OTLP/HTTP traces `URL` the otlp trace exporter sends the spans to")
+	repoDSN   = flag.String("repo-dsn", "", "connection `string` of the redis and mysql repositories")
+	repoOpts  = flag.String("repo-options", "", "repository backend specific `options`, e.g. 'pool-size=10,max-retries=3'")
+	cfgFile   = flag.String("config", "", "JSON config `file` keyed by flag name, also read from SCHEDULA_CONFIG. Flags override SCHEDULA_* environment variables, which override the file")
+	printCfg  = flag.Bool("print-config", false, "print the effective configuration as JSON and exit")
 )
 
 type config struct {
 	BindAddr        string
 	BindPort        int
-	NumberOfWorkers int
 	RepositoryType  string
+	Repository      repository.Config
 	SchedulerType   string
+	Scheduler       scheduler.Config
 	CallbackTimeout time.Duration
+	ShutdownGrace   time.Duration
+	Jobs            handler.JobsConfig
+	AuthEnabled     bool
+	AdminKey        string
+	ClientLimits    entity.ClientLimits
+	Logging         logging.Config
+	TraceExporter   string
+	TraceEndpoint   string
+	TickTolerance   time.Duration
+	EventHistory    int
 }
 
 func (c *config) ServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.BindAddr, c.BindPort)
 }
 
-func readConfig() *config {
+// commandLine are the flags given in the command line, which the config sources don't override
+var commandLine map[string]bool
+
+// readConfig returns the configuration along with the environment variables ignored, which are
+// logged once the logging is set up
+func readConfig() (*config, []ignoredVariable) {
 	flag.Parse()
-	callbackTimeout, err := time.ParseDuration(*timeout)
+	commandLine = givenFlags(flag.CommandLine)
+	ignored, err := loadConfigSources(flag.CommandLine, commandLine, os.Environ())
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	c, err := parseConfig()
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	if *printCfg {
+		if err := printConfig(os.Stdout, flag.CommandLine); err != nil {
+			log.Fatalf("unable to print configuration: %v", err)
+		}
+		os.Exit(0)
+	}
+	return c, ignored
+}
+
+// parseConfig validates the flag values, reporting every invalid one
+func parseConfig() (*config, error) {
+	var errs []string
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+	duration := func(name, value string) time.Duration {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			invalid("invalid %s duration: '%s'", name, value)
+		}
+		return d
+	}
+	callbackTimeout := duration("timeout", *timeout)
+	shutdownGrace := duration("shutdown-grace", *grace)
+	idempotencyWindow := duration("idempotency-window", *idemWin)
+	leaderLeaseTTL := duration("lease-ttl", *leaseTTL)
+	jobClaimTTL := duration("claim-ttl", *claimTTL)
+	tickTolerance := duration("ready-tick-tolerance", *readyTick)
+	hostIdleTimeout := duration("host-idle-timeout", *hostIdle)
+	breakerOpen := duration("breaker-open", *brkOpen)
+	if *bindPort < 1 || *bindPort > 65535 {
+		invalid("invalid port: '%d'", *bindPort)
+	}
+	if *nWorkers < 1 {
+		invalid("invalid number of workers: '%d'", *nWorkers)
+	}
+	if err := repository.CheckType(*repoType); err != nil {
+		invalid("%v", err)
+	}
+	repoOptions, err := parseOptions(*repoOpts)
+	if err != nil {
+		invalid("invalid repository options: %v", err)
+	}
+	if *schedType != "ticker" {
+		invalid("invalid scheduler type: '%s'", *schedType)
+	}
+	if *idemScope != "client" && *idemScope != "global" {
+		invalid("invalid idempotency scope: '%s'", *idemScope)
+	}
+	hostLimitRules, err := scheduler.ParseHostLimitRules(*hostRules)
+	if err != nil {
+		invalid("invalid host limits: %v", err)
+	}
+	allowHosts, err := scheduler.ParseHostPatterns(*allowHost)
 	if err != nil {
-		log.Fatalf("invalid timeout duration format: %v", err)
+		invalid("invalid callback allow list: %v", err)
+	}
+	denyHosts, err := scheduler.ParseHostPatterns(*denyHost)
+	if err != nil {
+		invalid("invalid callback deny list: %v", err)
+	}
+	if *maxPend < 0 || *crtRate < 0 || *crtBurst < 0 || *maxData < 0 {
+		invalid("invalid client limits: negative values")
+	}
+	if *hostRate < 0 || *hostBurst < 0 || *hostConc < 0 {
+		invalid("invalid host limits: negative values")
+	}
+	if *brkRate < 0 || *brkRate > 1 {
+		invalid("invalid circuit breaker failure rate: '%v'", *brkRate)
+	}
+	if _, err := logging.ParseLevel(*logLevel); err != nil {
+		invalid("%v", err)
+	}
+	if *logFormat != logging.FormatText && *logFormat != logging.FormatJSON {
+		invalid("invalid log format: '%s'", *logFormat)
+	}
+	if *traceExp != "" && *traceExp != "stdout" && *traceExp != "otlp" {
+		invalid("invalid trace exporter: '%s'", *traceExp)
+	}
+	if endpoint, err := url.Parse(*traceEnd); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		invalid("invalid trace endpoint: '%s'", *traceEnd)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	node := *nodeID
+	if node == "" {
+		hostname, _ := os.Hostname()
+		node = fmt.Sprintf("%s-%d", hostname, os.Getpid())
 	}
 
 	return &config{
-		BindAddr:        *bindAddr,
-		BindPort:        *bindPort,
-		NumberOfWorkers: *nWorkers,
-		RepositoryType:  *repoType,
-		SchedulerType:   *schedType,
+		BindAddr:       *bindAddr,
+		BindPort:       *bindPort,
+		RepositoryType: *repoType,
+		Repository: repository.Config{
+			InlineExecutions:   *inlineExe,
+			ExecutionRetention: *keepExe,
+			DSN:                *repoDSN,
+			Options:            repoOptions,
+		},
+		SchedulerType: *schedType,
+		Scheduler: scheduler.Config{
+			WorkersPerHost:  *nWorkers,
+			HostQueueSize:   *hostQueue,
+			HostIdleTimeout: hostIdleTimeout,
+			HostLimits:      scheduler.HostLimits{Rate: *hostRate, Burst: *hostBurst, MaxInFlight: *hostConc},
+			HostLimitRules:  hostLimitRules,
+			AllowHosts:      allowHosts,
+			DenyHosts:       denyHosts,
+			NodeID:          node,
+			LeaseTTL:        leaderLeaseTTL,
+			ClaimTTL:        jobClaimTTL,
+			Breaker: scheduler.BreakerConfig{
+				Window:       *brkWindow,
+				MinRequests:  *brkMinReq,
+				FailureRate:  *brkRate,
+				OpenDuration: breakerOpen,
+			},
+		},
 		CallbackTimeout: callbackTimeout,
-	}
+		ShutdownGrace:   shutdownGrace,
+		Jobs: handler.JobsConfig{
+			IdempotencyWindow:    idempotencyWindow,
+			IdempotencyPerClient: *idemScope == "client",
+		},
+		AuthEnabled: *auth,
+		AdminKey:    *adminKey,
+		ClientLimits: entity.ClientLimits{
+			MaxPendingJobs: *maxPend,
+			CreateRate:     *crtRate,
+			CreateBurst:    *crtBurst,
+			MaxDataSize:    *maxData,
+		},
+		Logging:       logging.Config{Level: *logLevel, Format: *logFormat},
+		TraceExporter: *traceExp,
+		TraceEndpoint: *traceEnd,
+		TickTolerance: tickTolerance,
+		EventHistory:  *evHistory,
+	}, nil
 }
 
 func main() {
-	log.Printf("Schedula Server v%s", version)
-	config := readConfig()
+	config, ignored := readConfig()
+	if err := logging.Setup(os.Stderr, config.Logging); err != nil {
+		log.Fatalf("invalid logging configuration: %v", err)
+	}
+	logIgnored(ignored)
+	logger.Info("Schedula Server", "version", version)
+
+	recorder := metrics.NewPrometheus()
+	bus := events.NewBus(config.EventHistory)
+	repository := metrics.NewJobs(events.NewJobs(initRepository(config.RepositoryType, config.Repository), bus), recorder)
+	callbacks := callback.NewSynchronousExecutor(config.CallbackTimeout)
+	executor := metrics.NewExecutor(callbacks, recorder)
+	if config.Scheduler.LeaseTTL > 0 {
+		config.Scheduler.Leases = initLeases(config.RepositoryType)
+	}
+	pauses := initPauses(config.RepositoryType)
+	config.Scheduler.Pauses = pauses
+	config.Jobs.Pauses = pauses
+	config.Scheduler.Metrics = recorder
+	config.Scheduler.Events = bus
+	tracer := initTracer(config.TraceExporter, config.TraceEndpoint)
+	config.Scheduler.Tracer = tracer
+	config.Jobs.Tracer = tracer
+	// the scheduler finishes jobs through the quotas too, keeping the pending jobs of each client
+	limitedRepository := quota.NewJobs(repository, config.ClientLimits)
+	scheduler := initScheduler(config.SchedulerType, limitedRepository, executor, config.Scheduler)
+	registerGauges(recorder.Registry, repository, scheduler)
+
+	apiKeys := initAPIKeys(config)
 
-	repository := initRepository(config.RepositoryType)
-	executor := initCallbackExecutor(config.CallbackTimeout)
-	scheduler := initScheduler(config.SchedulerType, repository, executor, config.NumberOfWorkers)
+	reloader := &reloader{scheduler: scheduler, executor: callbacks, quotas: limitedRepository}
 
-	jobs := handler.NewJobsHandler("/jobs", repository)
+	jobs := handler.NewJobsHandler("/jobs/", limitedRepository, config.Jobs)
+	keys := handler.NewAPIKeysHandler("/admin/keys/", apiKeys)
+	limits := handler.NewLimitsHandler(limitedRepository)
+	hosts := handler.NewHostsHandler(scheduler)
+	run := handler.NewRunHandler(limitedRepository, scheduler)
+	metricsHandler := handler.NewMetricsHandler(recorder)
+	eventsHandler := handler.NewEventsHandler(bus)
+	reload := handler.NewReloadHandler(reloader)
+	health := handler.NewHealthHandler(repository, scheduler, handler.HealthConfig{
+		Version:        version,
+		RepositoryType: config.RepositoryType,
+		SchedulerType:  config.SchedulerType,
+		TickTolerance:  config.TickTolerance,
+	})
 	router := mux.NewRouter()
 	router.HandleFunc("/jobs/", jobs.List).Methods("GET")
 	router.HandleFunc("/jobs/", jobs.Create).Methods("POST")
+	router.HandleFunc("/jobs/batch", jobs.CreateBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/cancel", jobs.CancelBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/pause", jobs.PauseBatch).Methods("POST")
+	router.HandleFunc("/jobs/batch/resume", jobs.ResumeBatch).Methods("POST")
 	router.HandleFunc("/jobs/{id}", jobs.Find).Methods("GET")
 	router.HandleFunc("/jobs/{id}", jobs.Delete).Methods("DELETE")
+	router.HandleFunc("/jobs/{id}/pause", jobs.Pause).Methods("POST")
+	router.HandleFunc("/jobs/{id}/resume", jobs.Resume).Methods("POST")
+	router.HandleFunc("/jobs/{id}/run", run.Run).Methods("POST")
+	router.HandleFunc("/jobs/{id}/executions", jobs.Executions).Methods("GET")
+	router.HandleFunc("/pauses", jobs.Pauses).Methods("GET")
+	router.HandleFunc("/events", eventsHandler.Stream).Methods("GET")
+	router.HandleFunc("/admin/keys/", keys.List).Methods("GET")
+	router.HandleFunc("/admin/keys/", keys.Create).Methods("POST")
+	router.HandleFunc("/admin/keys/{id}", keys.Revoke).Methods("DELETE")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Find).Methods("GET")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Update).Methods("PUT")
+	router.HandleFunc("/admin/clients/{clientKey}/limits", limits.Delete).Methods("DELETE")
+	router.HandleFunc("/admin/hosts/", hosts.List).Methods("GET")
+	router.HandleFunc("/admin/status", health.Status).Methods("GET")
+	router.HandleFunc("/admin/reload", reload.Reload).Methods("POST")
+	router.HandleFunc("/metrics", metricsHandler.Scrape).Methods("GET")
+
+	authenticator := handler.NewAuthenticator(apiKeys, config.AuthEnabled)
+
+	// probes don't authenticate
+	root := mux.NewRouter()
+	root.HandleFunc("/healthz", health.Live).Methods("GET")
+	root.HandleFunc("/readyz", health.Ready).Methods("GET")
+	root.PathPrefix("/").Handler(tracer.Middleware(authenticator.Wrap(router)))
+
+	server := &http.Server{Addr: config.ServerAddr(), Handler: root}
+	go func() {
+		logger.Info("listening", "addr", config.ServerAddr())
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			fatal("error serving API", err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			logger.Info("shutting down", "signal", sig)
+			break
+		}
+		if _, err := reloader.Reload(); err != nil {
+			logger.Error("error reloading configuration", logging.KeyError, err)
+		}
+	}
+	shutdown(server, scheduler, bus, config.ShutdownGrace)
+	tracer.Close()
+}
+
+// shutdown stops accepting API requests and stops the scheduler at once, waiting for both up to the
+// grace period. The event streams are closed once the scheduler published its last events, which
+// lets the server drain them.
+func shutdown(server *http.Server, s scheduler.Scheduler, bus *events.Bus, grace time.Duration) {
+	deadline := time.Now().Add(grace)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("error shutting down API server", logging.KeyError, err)
+		}
+	}()
+	if err := s.Shutdown(time.Until(deadline)); err != nil {
+		logger.Error("error shutting down scheduler", logging.KeyError, err)
+	}
+	bus.Close()
+	<-drained
+}
 
-	log.Printf("Listening on %s", config.ServerAddr())
-	log.Fatal(http.ListenAndServe(config.ServerAddr(), router))
+// registerGauges registers the gauges of the pending jobs and of the callback pipeline of each host
+func registerGauges(r *metrics.Registry, jobs repository.Jobs, s scheduler.Scheduler) {
+	r.Gauge("schedula_jobs_pending", "Number of pending jobs.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(jobs.Count(repository.JobFilter{Status: entity.JobStatusPending}))}}
+	})
+	hostGauge := func(value func(scheduler.HostStatus) float64) func() []metrics.Sample {
+		return func() []metrics.Sample {
+			hosts := s.Hosts()
+			samples := make([]metrics.Sample, len(hosts))
+			for i, host := range hosts {
+				samples[i] = metrics.Sample{Labels: []string{host.Host}, Value: value(host)}
+			}
+			return samples
+		}
+	}
+	r.Gauge("schedula_host_queue_depth", "Number of callbacks queued, by host.", []string{"host"}, hostGauge(func(h scheduler.HostStatus) float64 {
+		return float64(h.QueueLength)
+	}))
+	r.Gauge("schedula_host_busy_workers", "Number of workers executing callbacks, by host.", []string{"host"}, hostGauge(func(h scheduler.HostStatus) float64 {
+		return float64(h.Busy)
+	}))
+	r.Gauge("schedula_host_breaker_open", "Whether the circuit breaker of the host is open.", []string{"host"}, hostGauge(func(h scheduler.HostStatus) float64 {
+		if h.Breaker.State == scheduler.BreakerOpen {
+			return 1
+		}
+		return 0
+	}))
+}
 
-	scheduler.Stop()
+// fatal logs the error and exits
+func fatal(msg string, err error) {
+	logger.Error(msg, logging.KeyError, err)
+	os.Exit(1)
 }
 
-func initRepository(repoType string) repository.Jobs {
-	repository, err := repository.New(repoType)
+func initRepository(repoType string, c repository.Config) repository.Jobs {
+	repository, err := repository.NewWithConfig(repoType, c)
 	if err != nil {
-		log.Fatalf("schedula: error initializing repository: %v", err)
+		fatal("error initializing repository", err)
 	}
 	return repository
 }
 
-func initCallbackExecutor(httpTimeout time.Duration) callback.Executor {
-	executor, err := callback.NewExecutor(httpTimeout)
+func initLeases(repoType string) repository.Leases {
+	leases, err := repository.NewLeases(repoType)
+	if err != nil {
+		fatal("error initializing leases repository", err)
+	}
+	return leases
+}
+
+func initPauses(repoType string) repository.Pauses {
+	pauses, err := repository.NewPauses(repoType)
+	if err != nil {
+		fatal("error initializing pauses repository", err)
+	}
+	return pauses
+}
+
+func initAPIKeys(c *config) repository.APIKeys {
+	keys, err := repository.NewAPIKeys(c.RepositoryType)
+	if err != nil {
+		fatal("error initializing API keys repository", err)
+	}
+	if !c.AuthEnabled {
+		return keys
+	}
+	admin, err := keys.Add(entity.APIKey{Secret: c.AdminKey, Role: entity.RoleAdmin})
+	if err != nil {
+		fatal("error adding admin API key", err)
+	}
+	if c.AdminKey == "" {
+		logger.Info("authentication enabled", "admin_key", admin.Secret)
+	}
+	return keys
+}
+
+// initTracer returns a tracer with an exporter of the given type, nil when blank
+func initTracer(exporterType string, endpoint string) *tracing.Tracer {
+	if exporterType == "" {
+		return nil
+	}
+	exporter, err := tracing.NewExporter(exporterType, tracing.ExporterConfig{
+		Writer: os.Stdout,
+		OTLP:   tracing.OTLPConfig{Endpoint: endpoint, ServiceName: "schedula"},
+	})
 	if err != nil {
-		log.Fatalf("schedula: error initializing callback executor: %v", err)
+		fatal("error initializing tracing", err)
 	}
-	return executor
+	return tracing.NewTracer(exporter)
 }
 
-func initScheduler(schedulerType string, r repository.Jobs, e callback.Executor, nWorkers int) scheduler.Scheduler {
-	scheduler, err := scheduler.StartNew(schedulerType, r, e, scheduler.Config{WorkersPerHost: nWorkers})
+func initScheduler(schedulerType string, r repository.Jobs, e callback.Executor, c scheduler.Config) scheduler.Scheduler {
+	scheduler, err := scheduler.StartNew(schedulerType, r, e, c)
 	if err != nil {
-		log.Fatalf("schedula: error initializing scheduler: %v", err)
+		fatal("error initializing scheduler", err)
 	}
 	return scheduler
 }
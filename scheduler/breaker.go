@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BreakerClosed lets every callback through
+	BreakerClosed = "closed"
+
+	// BreakerOpen defers every callback until the breaker cools down
+	BreakerOpen = "open"
+
+	// BreakerHalfOpen lets a single probe callback through to decide whether to close the breaker
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerConfig holds circuit breaker parameters. The breaker opens when at least FailureRate
+// (0 to 1) of the last Window callbacks failed, as long as there were MinRequests of them, and
+// stays open for OpenDuration. A zero Window disables the breaker.
+type BreakerConfig struct {
+	Window       int
+	MinRequests  int
+	FailureRate  float64
+	OpenDuration time.Duration
+}
+
+// BreakerStatus is a snapshot of a Breaker
+type BreakerStatus struct {
+	State       string    `json:"state"`
+	FailureRate float64   `json:"failureRate"`
+	Requests    int       `json:"requests"`
+	OpenedAt    time.Time `json:"openedAt"`
+	RetryAt     time.Time `json:"retryAt"`
+}
+
+// Breaker is a circuit breaker driven by the failure rate of the last callbacks sent to a host
+type Breaker struct {
+	sync.Mutex
+	config   BreakerConfig
+	state    string
+	outcomes []bool
+	next     int
+	count    int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed Breaker
+func NewBreaker(c BreakerConfig) *Breaker {
+	b := &Breaker{config: c, state: BreakerClosed}
+	if c.Window > 0 {
+		b.outcomes = make([]bool, c.Window)
+	}
+	return b
+}
+
+// Allow returns whether a callback can be attempted at the given time. When it can't, it also
+// returns the time the callback should be deferred to.
+func (b *Breaker) Allow(now time.Time) (bool, time.Time) {
+	if b.config.Window <= 0 {
+		return true, time.Time{}
+	}
+	b.Lock()
+	defer b.Unlock()
+
+	retryAt := b.openedAt.Add(b.config.OpenDuration)
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(retryAt) {
+			return false, retryAt
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true, time.Time{}
+	case BreakerHalfOpen:
+		if b.probing {
+			// wait for the probe outcome, which won't take longer than a callback timeout
+			return false, now.Add(time.Second)
+		}
+		b.probing = true
+	}
+	return true, time.Time{}
+}
+
+// Cancel gives up a callback allowed by the breaker without sending it, so a half-open breaker
+// lets the next one probe the host instead of waiting for an outcome that never comes
+func (b *Breaker) Cancel() {
+	if b.config.Window <= 0 {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	b.probing = false
+}
+
+// Record registers the outcome of a callback allowed by the breaker, returning the breaker
+// state if it changed or a blank string otherwise.
+func (b *Breaker) Record(success bool, now time.Time) string {
+	if b.config.Window <= 0 {
+		return ""
+	}
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if success {
+			b.reset()
+			b.state = BreakerClosed
+		} else {
+			b.state = BreakerOpen
+			b.openedAt = now
+		}
+		return b.state
+	}
+
+	if b.count == len(b.outcomes) && !b.outcomes[b.next] {
+		b.failures--
+	}
+	if b.count < len(b.outcomes) {
+		b.count++
+	}
+	b.outcomes[b.next] = success
+	b.next = (b.next + 1) % len(b.outcomes)
+	if !success {
+		b.failures++
+	}
+
+	if b.state == BreakerClosed && b.count >= b.config.MinRequests && b.failureRate() >= b.config.FailureRate {
+		b.state = BreakerOpen
+		b.openedAt = now
+		b.reset()
+		return b.state
+	}
+	return ""
+}
+
+// Status returns a snapshot of the breaker
+func (b *Breaker) Status() BreakerStatus {
+	b.Lock()
+	defer b.Unlock()
+	status := BreakerStatus{State: b.state, FailureRate: b.failureRate(), Requests: b.count}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+		status.RetryAt = b.openedAt.Add(b.config.OpenDuration)
+	}
+	return status
+}
+
+func (b *Breaker) failureRate() float64 {
+	if b.count == 0 {
+		return 0
+	}
+	return float64(b.failures) / float64(b.count)
+}
+
+func (b *Breaker) reset() {
+	b.count, b.next, b.failures = 0, 0, 0
+}
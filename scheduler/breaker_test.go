@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func assertBreakerState(t *testing.T, b *Breaker, expected string) {
+	if state := b.Status().State; state != expected {
+		t.Fatalf("expected breaker to be %s but it's %s", expected, state)
+	}
+}
+
+func TestBreakerOpens(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBreaker(BreakerConfig{Window: 4, MinRequests: 4, FailureRate: 0.5, OpenDuration: 30 * time.Second})
+	b.Record(true, now)
+	b.Record(false, now)
+	b.Record(true, now)
+	assertBreakerState(t, b, BreakerClosed)
+	if state := b.Record(false, now); state != BreakerOpen {
+		t.Fatalf("expected breaker to open on 50%% failures but got '%s'", state)
+	}
+
+	allowed, retryAt := b.Allow(now.Add(10 * time.Second))
+	if allowed || !retryAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("expected callbacks to be deferred to %v but got allowed=%v, retryAt=%v", now.Add(30*time.Second), allowed, retryAt)
+	}
+}
+
+func TestBreakerSlidingWindow(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBreaker(BreakerConfig{Window: 3, MinRequests: 3, FailureRate: 0.6, OpenDuration: time.Second})
+	for _, success := range []bool{false, true, true, false, true, true} {
+		b.Record(success, now)
+	}
+	assertBreakerState(t, b, BreakerClosed)
+	if rate := b.Status().FailureRate; rate < 0.33 || rate > 0.34 {
+		t.Fatalf("expected failure rate of the last 3 callbacks to be 1/3 but got %v", rate)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBreaker(BreakerConfig{Window: 1, MinRequests: 1, FailureRate: 1, OpenDuration: 30 * time.Second})
+	b.Record(false, now)
+	assertBreakerState(t, b, BreakerOpen)
+
+	// a single probe is let through after the breaker cools down
+	now = now.Add(30 * time.Second)
+	if allowed, _ := b.Allow(now); !allowed {
+		t.Fatalf("expected probe callback to be allowed")
+	}
+	assertBreakerState(t, b, BreakerHalfOpen)
+	if allowed, _ := b.Allow(now); allowed {
+		t.Fatalf("expected callbacks to be deferred while probing")
+	}
+
+	// failed probe opens the breaker again
+	b.Record(false, now)
+	assertBreakerState(t, b, BreakerOpen)
+
+	now = now.Add(30 * time.Second)
+	b.Allow(now)
+	b.Record(true, now)
+	assertBreakerState(t, b, BreakerClosed)
+}
+
+func TestBreakerCanceledProbe(t *testing.T) {
+	now := time.Unix(1438948984, 0)
+	b := NewBreaker(BreakerConfig{Window: 1, MinRequests: 1, FailureRate: 1, OpenDuration: 30 * time.Second})
+	b.Record(false, now)
+
+	now = now.Add(30 * time.Second)
+	b.Allow(now)
+	b.Cancel()
+	if allowed, _ := b.Allow(now); !allowed {
+		t.Fatalf("expected another probe to be allowed after the first one was canceled")
+	}
+	assertBreakerState(t, b, BreakerHalfOpen)
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(BreakerConfig{})
+	b.Record(false, time.Now())
+	if allowed, _ := b.Allow(time.Now()); !allowed {
+		t.Fatalf("expected disabled breaker to allow every callback")
+	}
+}
@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// cluster is a harness running several schedulers that share the jobs and leases repositories,
+// driven by a fake clock instead of their tickers
+type cluster struct {
+	t        *testing.T
+	jobs     repository.Jobs
+	leases   repository.Leases
+	executor *CallbackExecutorMock
+	nodes    map[string]*TickerScheduler
+	clock    *fakeClock
+}
+
+// fakeClock is a clock only moved forward by the tests
+type fakeClock struct {
+	sync.Mutex
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) time.Time {
+	c.Lock()
+	defer c.Unlock()
+	c.t = c.t.Add(d)
+	return c.t
+}
+
+func newCluster(t *testing.T, start time.Time) *cluster {
+	jobs, _ := repository.NewJobsInMemoryWithMutex()
+	leases, _ := repository.NewLeasesInMemory()
+	return &cluster{
+		t:        t,
+		jobs:     jobs,
+		leases:   leases,
+		executor: &CallbackExecutorMock{},
+		nodes:    make(map[string]*TickerScheduler),
+		clock:    &fakeClock{t: start},
+	}
+}
+
+func (c *cluster) join(node string) {
+	c.add(node, c.executor, Config{
+		WorkersPerHost: 2,
+		Leases:         c.leases,
+		NodeID:         node,
+		LeaseTTL:       3 * time.Second,
+	})
+}
+
+// add starts a node with the given executor and configuration, driven by the cluster clock
+func (c *cluster) add(node string, e callback.Executor, config Config) *TickerScheduler {
+	s := NewTickerScheduler(c.jobs, e, config)
+	s.now = c.clock.Now
+	c.nodes[node] = s
+	return s
+}
+
+// crash removes the node without releasing its lease
+func (c *cluster) crash(node string) {
+	delete(c.nodes, node)
+}
+
+// leave shuts the node down, releasing its lease
+func (c *cluster) leave(node string) {
+	if err := c.nodes[node].Shutdown(time.Second); err != nil {
+		c.t.Fatalf("error shutting down node %s: %v", node, err)
+	}
+	delete(c.nodes, node)
+}
+
+func (c *cluster) leader() string {
+	for node, s := range c.nodes {
+		if s.elector.IsLeader() {
+			return node
+		}
+	}
+	return ""
+}
+
+// advance moves the clock forward, ticking every node each second in random order and waiting
+// for the callbacks they published to complete
+func (c *cluster) advance(seconds int) {
+	for i := 0; i < seconds; i++ {
+		now := c.clock.Add(time.Second)
+		for _, s := range c.nodes {
+			s.tickAt(now)
+		}
+		waitFor(c.t, c.idle)
+	}
+}
+
+// idle returns whether no node holds job claims
+func (c *cluster) idle() bool {
+	for _, s := range c.nodes {
+		s.claimsMutex.Lock()
+		n := len(s.claims)
+		s.claimsMutex.Unlock()
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *cluster) schedule(timestamp int64, n int) []entity.Job {
+	jobs := make([]entity.Job, n)
+	for i := range jobs {
+		job, err := c.jobs.Add(entity.Job{
+			CallbackURL: fmt.Sprintf("http://host-%d.example.com/callback", i),
+			Schedule:    entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: strconv.FormatInt(timestamp, 10)},
+		})
+		if err != nil {
+			c.t.Fatalf("error adding job: %v", err)
+		}
+		jobs[i] = job
+	}
+	return jobs
+}
+
+func TestCluster_JobsFireExactlyOnce(t *testing.T) {
+	start := time.Unix(1000000000, 0)
+	c := newCluster(t, start)
+	jobs := make([]entity.Job, 0)
+	for ts := start.Unix() + 1; ts <= start.Unix()+60; ts++ {
+		jobs = append(jobs, c.schedule(ts, 3)...)
+	}
+	executed := func(n int) func() bool {
+		return func() bool { return c.executor.Counter("Execute") >= n }
+	}
+
+	c.join("node-1")
+	c.join("node-2")
+	c.join("node-3")
+	c.advance(10)
+	waitFor(t, executed(30))
+
+	c.crash(c.leader())
+	c.advance(10)
+	c.join("node-4")
+	c.advance(10)
+	waitFor(t, executed(90))
+
+	c.leave(c.leader())
+	c.advance(5)
+	for node := range c.nodes {
+		c.crash(node)
+	}
+	c.advance(5)
+	c.join("node-5")
+	c.advance(30)
+
+	waitFor(t, executed(len(jobs)))
+	time.Sleep(50 * time.Millisecond)
+	for _, job := range jobs {
+		if n := c.executor.Counter(job.ID); n != 1 {
+			t.Fatalf("expected job %s scheduled at %d to fire once but it fired %d times", job.ID, job.NextRun, n)
+		}
+	}
+}
+
+func TestCluster_ExpiredClaimsAreRetried(t *testing.T) {
+	start := time.Unix(1000000000, 0)
+	c := newCluster(t, start)
+	job := c.schedule(start.Unix()+1, 1)[0]
+
+	crashing := &ExecutionIDRecorderMock{release: make(chan bool)}
+	c.add("node-1", crashing, Config{WorkersPerHost: 1, NodeID: "node-1", ClaimTTL: 10 * time.Second})
+	c.nodes["node-1"].tickAt(c.clock.Add(time.Second))
+	waitFor(t, func() bool { return len(crashing.IDs()) == 1 })
+	c.crash("node-1")
+
+	retrying := &ExecutionIDRecorderMock{}
+	c.add("node-2", retrying, Config{WorkersPerHost: 1, NodeID: "node-2", ClaimTTL: 10 * time.Second})
+	c.advance(9)
+	if n := len(retrying.IDs()); n != 0 {
+		t.Fatalf("expected callback not to be retried before the claim expires but got %d", n)
+	}
+	c.advance(1)
+	waitFor(t, func() bool { return len(retrying.IDs()) == 1 })
+	if retrying.IDs()[0] != crashing.IDs()[0] {
+		t.Fatalf("expected retry with execution ID %s but got %s", crashing.IDs()[0], retrying.IDs()[0])
+	}
+	if job, _ := c.jobs.Get(job.ID); job.Status != entity.JobStatusSuccess {
+		t.Fatalf("expected retried job status to be '%s' but got '%s'", entity.JobStatusSuccess, job.Status)
+	}
+
+	close(crashing.release)
+}
+
+// ExecutionIDRecorderMock records the execution ID of the callbacks, blocking until released if
+// release isn't nil
+type ExecutionIDRecorderMock struct {
+	sync.Mutex
+	ids     []string
+	release chan bool
+}
+
+func (e *ExecutionIDRecorderMock) Execute(job entity.Job) error {
+	e.Lock()
+	e.ids = append(e.ids, job.Claim.ExecutionID)
+	e.Unlock()
+	if e.release != nil {
+		<-e.release
+	}
+	return nil
+}
+
+func (e *ExecutionIDRecorderMock) IDs() []string {
+	e.Lock()
+	defer e.Unlock()
+	return append([]string(nil), e.ids...)
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/repository"
+)
+
+// LeaderLeaseName is the name of the lease held by the node publishing the scheduled jobs
+const LeaderLeaseName = "scheduler-leader"
+
+// Elector elects a single node among the ones sharing a leases repository as the leader, the
+// only one publishing scheduled jobs. The leader lease checkpoint is the last second published,
+// so a new leader publishes the seconds missed while the lease was expiring, and no second is
+// published twice.
+type Elector struct {
+	sync.Mutex
+	leases repository.Leases
+	node   string
+	ttl    time.Duration
+	leader bool
+}
+
+// NewElector creates an Elector for the given node. The leader has to renew its lease within
+// ttl, so it must be longer than the scheduler tick interval.
+func NewElector(leases repository.Leases, node string, ttl time.Duration) *Elector {
+	return &Elector{leases: leases, node: node, ttl: ttl}
+}
+
+// Acquire acquires or renews the leader lease, returning it and whether the node is the leader
+func (e *Elector) Acquire(now time.Time) (entity.Lease, bool) {
+	e.Lock()
+	defer e.Unlock()
+
+	lease, err := e.leases.Acquire(LeaderLeaseName, e.node, e.ttl, now)
+	if err != nil && err != repository.ErrLeaseHeld {
+		logger.Error("error acquiring leader lease", "node", e.node, logging.KeyError, err)
+	}
+	e.transition(err == nil)
+	return lease, err == nil
+}
+
+// Advance moves the leader lease checkpoint to the given timestamp, returning whether the node
+// is still the leader and can publish the jobs scheduled at it
+func (e *Elector) Advance(timestamp int64, now time.Time) bool {
+	e.Lock()
+	defer e.Unlock()
+
+	_, err := e.leases.Checkpoint(LeaderLeaseName, e.node, timestamp, now)
+	if err != nil && err != repository.ErrLeaseHeld {
+		logger.Error("error advancing leader lease", "node", e.node, "checkpoint", timestamp, logging.KeyError, err)
+	}
+	e.transition(err == nil)
+	return err == nil
+}
+
+// Release gives up the leader lease so another node can take over without waiting it to expire
+func (e *Elector) Release() {
+	e.Lock()
+	defer e.Unlock()
+
+	if !e.leader {
+		return
+	}
+	if err := e.leases.Release(LeaderLeaseName, e.node); err != nil {
+		logger.Error("error releasing leader lease", "node", e.node, logging.KeyError, err)
+	}
+	e.transition(false)
+}
+
+// IsLeader returns whether the node was the leader on its last lease operation
+func (e *Elector) IsLeader() bool {
+	e.Lock()
+	defer e.Unlock()
+	return e.leader
+}
+
+// transition records the leadership state, the caller must hold the lock
+func (e *Elector) transition(leader bool) {
+	if leader != e.leader {
+		if leader {
+			logger.Info("node is now the leader", "node", e.node)
+		} else {
+			logger.Info("node is no longer the leader", "node", e.node)
+		}
+	}
+	e.leader = leader
+}
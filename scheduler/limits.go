@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// HostLimits restricts the callbacks sent to a host: Rate requests per second with bursts of up
+// to Burst requests, and at most MaxInFlight concurrent requests. Zero values disable the
+// corresponding limit.
+type HostLimits struct {
+	Rate        float64
+	Burst       int
+	MaxInFlight int
+}
+
+// HostLimitRule overrides the default host limits for hosts matching Pattern, a shell pattern
+// as accepted by path.Match, e.g. "*.example.com" or "localhost:*".
+type HostLimitRule struct {
+	Pattern string
+	Limits  HostLimits
+}
+
+// LimitsFor returns the limits of the first rule matching the given host, or the default ones
+func (c *Config) LimitsFor(host string) HostLimits {
+	for _, rule := range c.HostLimitRules {
+		if matched, _ := path.Match(rule.Pattern, host); matched {
+			return rule.Limits
+		}
+	}
+	return c.HostLimits
+}
+
+// Allowed returns whether callbacks can be sent to the given host according to the AllowHosts and
+// DenyHosts patterns
+func (c *Config) Allowed(host string) bool {
+	if matchHost(c.DenyHosts, host) {
+		return false
+	}
+	return len(c.AllowHosts) == 0 || matchHost(c.AllowHosts, host)
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHostPatterns parses a comma separated list of host shell patterns, e.g.
+// "*.example.com,localhost:*".
+func ParseHostPatterns(s string) ([]string, error) {
+	patterns := make([]string, 0)
+	if strings.TrimSpace(s) == "" {
+		return patterns, nil
+	}
+	for _, p := range strings.Split(s, ",") {
+		pattern := strings.TrimSpace(p)
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("invalid host pattern: '%s'", p)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// ParseHostLimitRules parses a comma separated list of host limit rules in the format
+// 'pattern=rate:burst:maxInFlight', e.g. "*.example.com=10:20:5,localhost:*=0:0:1".
+func ParseHostLimitRules(s string) ([]HostLimitRule, error) {
+	rules := make([]HostLimitRule, 0)
+	if strings.TrimSpace(s) == "" {
+		return rules, nil
+	}
+	for _, r := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(r), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid host limit rule: '%s'", r)
+		}
+		if _, err := path.Match(parts[0], ""); err != nil {
+			return nil, fmt.Errorf("invalid host pattern in rule '%s': %v", r, err)
+		}
+		values := strings.Split(parts[1], ":")
+		if len(values) != 3 {
+			return nil, fmt.Errorf("invalid host limits in rule '%s', expected rate:burst:maxInFlight", r)
+		}
+		rate, rateErr := strconv.ParseFloat(values[0], 64)
+		burst, burstErr := strconv.Atoi(values[1])
+		inFlight, inFlightErr := strconv.Atoi(values[2])
+		if rateErr != nil || burstErr != nil || inFlightErr != nil {
+			return nil, fmt.Errorf("invalid host limits in rule '%s', expected rate:burst:maxInFlight", r)
+		}
+		rules = append(rules, HostLimitRule{
+			Pattern: parts[0],
+			Limits:  HostLimits{Rate: rate, Burst: burst, MaxInFlight: inFlight},
+		})
+	}
+	return rules, nil
+}
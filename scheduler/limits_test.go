@@ -0,0 +1,68 @@
+package scheduler
+
+import "testing"
+
+func TestParseHostLimitRules(t *testing.T) {
+	rules, err := ParseHostLimitRules("*.example.com=10:20:5, localhost:*=0.5:1:0")
+	if err != nil {
+		t.Fatalf("unable to parse host limit rules: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules but got %d", len(rules))
+	}
+	expected := HostLimits{Rate: 0.5, Burst: 1}
+	if rules[1].Pattern != "localhost:*" || rules[1].Limits != expected {
+		t.Fatalf("expected rule 'localhost:*' with %+v but got '%s' with %+v", expected, rules[1].Pattern, rules[1].Limits)
+	}
+}
+
+func TestParseHostLimitRulesInvalid(t *testing.T) {
+	for _, s := range []string{"example.com", "example.com=1:2", "example.com=a:1:1", "[=1:1:1"} {
+		if _, err := ParseHostLimitRules(s); err == nil {
+			t.Fatalf("expected an error parsing '%s'", s)
+		}
+	}
+}
+
+func TestConfigLimitsFor(t *testing.T) {
+	c := Config{
+		HostLimits: HostLimits{Rate: 100},
+		HostLimitRules: []HostLimitRule{
+			{Pattern: "*.example.com", Limits: HostLimits{Rate: 10, MaxInFlight: 2}},
+			{Pattern: "*", Limits: HostLimits{Rate: 1}},
+		},
+	}
+	if limits := c.LimitsFor("api.example.com"); limits.Rate != 10 || limits.MaxInFlight != 2 {
+		t.Fatalf("expected first matching rule limits but got %+v", limits)
+	}
+	if limits := c.LimitsFor("localhost:8088"); limits.Rate != 1 {
+		t.Fatalf("expected catch-all rule limits but got %+v", limits)
+	}
+	c.HostLimitRules = nil
+	if limits := c.LimitsFor("localhost:8088"); limits.Rate != 100 {
+		t.Fatalf("expected default limits but got %+v", limits)
+	}
+}
+
+func TestConfigAllowed(t *testing.T) {
+	c := Config{AllowHosts: []string{"*.example.com", "localhost:*"}, DenyHosts: []string{"admin.example.com"}}
+	cases := map[string]bool{"api.example.com": true, "localhost:8088": true, "admin.example.com": false, "other.com": false}
+	for host, expected := range cases {
+		if allowed := c.Allowed(host); allowed != expected {
+			t.Fatalf("expected host '%s' allowed to be %v", host, expected)
+		}
+	}
+	if !(&Config{}).Allowed("other.com") {
+		t.Fatalf("expected every host to be allowed without allow list")
+	}
+}
+
+func TestParseHostPatterns(t *testing.T) {
+	patterns, err := ParseHostPatterns("*.example.com, localhost:*")
+	if err != nil || len(patterns) != 2 || patterns[1] != "localhost:*" {
+		t.Fatalf("unexpected patterns %v, error %v", patterns, err)
+	}
+	if _, err := ParseHostPatterns("example.com,["); err == nil {
+		t.Fatalf("expected an error parsing invalid pattern")
+	}
+}
@@ -1,10 +1,16 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/marcoshack/schedula/callback"
+	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+	"github.com/marcoshack/schedula/metrics"
 	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
 )
 
 const (
@@ -13,17 +19,129 @@ const (
 
 	// DefaultNumberOfWorkers is the number of go routines spawned to execute callbacks for each tick
 	DefaultNumberOfWorkers = 50
+
+	// DefaultHostQueueSize is the number of callbacks queued for each host
+	DefaultHostQueueSize = 1000
+
+	// DefaultClaimTTL is how long a job claim lasts without being renewed
+	DefaultClaimTTL = 30 * time.Second
 )
 
+// ErrHostQueueFull is returned when a callback doesn't fit in the queue of its host
+var ErrHostQueueFull = errors.New("host queue is full")
+
+// ErrSchedulerStopped is returned when a callback is sent to a scheduler shutting down
+var ErrSchedulerStopped = errors.New("scheduler is stopped")
+
+// ErrHostNotAllowed is the execution error of callbacks to hosts denied by the configuration
+var ErrHostNotAllowed = errors.New("callback host is not allowed")
+
+// BreakerOpenError is returned when a callback is sent to a host whose circuit breaker is open
+type BreakerOpenError struct {
+	Host    string
+	RetryAt time.Time
+}
+
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of host %s is open until %v", e.Host, e.RetryAt)
+}
+
 // Scheduler is a service to schedule jobs
 type Scheduler interface {
 	Start() error
 	Stop() error
+	Shutdown(grace time.Duration) error
+	Hosts() []HostStatus
+	Status() Status
+	Run(job entity.Job) (entity.JobExecution, error)
+	Reload(c RuntimeConfig)
 }
 
 // Config holds Scheduler configuration parameters
 type Config struct {
 	WorkersPerHost int
+	// HostQueueSize is the number of callbacks queued for each host, callbacks exceeding it are
+	// deferred in the repository. Defaults to DefaultHostQueueSize.
+	HostQueueSize int
+	// HostIdleTimeout is how long a host without callbacks keeps its queue and workers. Zero
+	// keeps them forever.
+	HostIdleTimeout time.Duration
+	HostLimits      HostLimits
+	HostLimitRules  []HostLimitRule
+	// AllowHosts restricts the callbacks to the hosts matching one of its shell patterns. Empty
+	// allows every host.
+	AllowHosts []string
+	// DenyHosts rejects the callbacks to the hosts matching one of its shell patterns, even when
+	// allowed by AllowHosts
+	DenyHosts []string
+	Breaker   BreakerConfig
+	// Leases coordinates the nodes sharing it, so only the leader node publishes the scheduled
+	// jobs. Nil runs the scheduler standalone.
+	Leases repository.Leases
+	// NodeID identifies the node among the ones sharing Leases
+	NodeID string
+	// LeaseTTL is how long the leader lease lasts without being renewed
+	LeaseTTL time.Duration
+	// ClaimTTL is how long the claim on a published job lasts without being renewed, after that
+	// the job is claimed again and its callback retried. Defaults to DefaultClaimTTL.
+	ClaimTTL time.Duration
+	// Metrics records the scheduler tick lag. Nil discards it.
+	Metrics metrics.Recorder
+	// Tracer traces the dispatch of each job, linked to the trace of its creation. Nil disables
+	// tracing.
+	Tracer *tracing.Tracer
+	// Events publishes an event when a job callback is dispatched. Nil publishes none.
+	Events events.Publisher
+	// Pauses holds the due jobs of the paused clients and hosts, pausing them instead of sending
+	// their callbacks. Nil pauses none.
+	Pauses repository.Pauses
+}
+
+// RuntimeConfig holds the Config parameters that can be changed while the scheduler runs
+type RuntimeConfig struct {
+	WorkersPerHost int
+	HostLimits     HostLimits
+	HostLimitRules []HostLimitRule
+	AllowHosts     []string
+	DenyHosts      []string
+}
+
+// claimTTL returns the ClaimTTL or its default
+func (c *Config) claimTTL() time.Duration {
+	if c.ClaimTTL <= 0 {
+		return DefaultClaimTTL
+	}
+	return c.ClaimTTL
+}
+
+// recorder returns the Metrics recorder or a Nop one
+func (c *Config) recorder() metrics.Recorder {
+	if c.Metrics == nil {
+		return metrics.Nop{}
+	}
+	return c.Metrics
+}
+
+// Status describes the scheduler loop. TickLag is the delay of the last callback fired after the
+// time it was scheduled to.
+type Status struct {
+	Running  bool          `json:"running"`
+	Leader   bool          `json:"leader"`
+	LastTick time.Time     `json:"lastTick"`
+	TickLag  time.Duration `json:"-"`
+}
+
+// HostStatus describes the callback pipeline of a host
+type HostStatus struct {
+	Host        string        `json:"host"`
+	Workers     int           `json:"workers"`
+	QueueLength int           `json:"queueLength"`
+	QueueSize   int           `json:"queueSize"`
+	Busy        int           `json:"busy"`
+	LastUsed    time.Time     `json:"lastUsed"`
+	Limits      HostLimits    `json:"limits"`
+	Allowed     bool          `json:"allowed"`
+	Breaker     BreakerStatus `json:"breaker"`
 }
 
 // New creates a Scheduler instance of the given type.
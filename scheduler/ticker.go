@@ -2,15 +2,24 @@ package scheduler
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/marcoshack/schedula/callback"
 	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+	"github.com/marcoshack/schedula/logging"
+	"github.com/marcoshack/schedula/ratelimit"
 	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
 )
 
+var logger = logging.Component("scheduler")
+
 // TickerScheduler implements Scheduler interface using non-replicated in-memory data structure.
 // This is a example implementation and should be used only for test purposes.
 type TickerScheduler struct {
@@ -19,26 +28,109 @@ type TickerScheduler struct {
 	ticker           *time.Ticker
 	jobs             repository.Jobs
 	callbackExecutor callback.Executor
-	HostContexts     map[string]*HostContext
+	hostContexts     map[string]*HostContext
+	hostsMutex       sync.RWMutex
+	elector          *Elector
+	claims           map[string]bool
+	claimsMutex      sync.Mutex
+	now              func() time.Time
+	stopped          bool
+	quit             chan struct{}
+	workers          sync.WaitGroup
+	running          int32
+	lastTick         int64
+	tickLag          int64
 }
 
-// HostContext ...
+// HostContext holds the callback queue and workers of a host. Its fields are guarded by the
+// scheduler hostsMutex, except for the ones used by the workers.
 type HostContext struct {
 	Host     string
-	C        chan entity.Job
+	queue    chan call
 	LastUsed time.Time
 	Workers  int
+	Limits   HostLimits
+	Breaker  *Breaker
+	enforcer atomic.Value
+	stops    []chan struct{}
+	active   int32
+	stopping int32
+}
+
+// hostPolicy enforces the limits and access rules of a host. It's replaced as a whole when the
+// configuration is reloaded, so a callback releases the in-flight slot of the policy it took it from.
+type hostPolicy struct {
+	allowed  bool
+	limiter  *ratelimit.Bucket
+	inFlight chan struct{}
+}
+
+func newHostPolicy(limits HostLimits, allowed bool) *hostPolicy {
+	p := &hostPolicy{allowed: allowed, limiter: ratelimit.NewBucket(limits.Rate, limits.Burst)}
+	if limits.MaxInFlight > 0 {
+		p.inFlight = make(chan struct{}, limits.MaxInFlight)
+	}
+	return p
+}
+
+// call is a job callback queued for a host. Manual calls, triggered outside the job schedule,
+// have a channel receiving their result. The dispatch span lasts until the call is handled, while
+// the queued span ends when a worker takes the call.
+type call struct {
+	job    entity.Job
+	result chan runResult
+	span   *tracing.Span
+	queued *tracing.Span
+}
+
+type runResult struct {
+	execution entity.JobExecution
+	err       error
+}
+
+// idle returns whether the host had no callbacks queued or executing since the given time
+func (c *HostContext) idle(since time.Time) bool {
+	return c.LastUsed.Before(since) && len(c.queue) == 0 && atomic.LoadInt32(&c.active) == 0
+}
+
+// policy returns the policy currently enforced on the host
+func (c *HostContext) policy() *hostPolicy {
+	return c.enforcer.Load().(*hostPolicy)
+}
+
+// wait blocks until a callback can be sent to the host according to its limits. Callbacks
+// over the rate limit are delayed rather than dropped.
+func (p *hostPolicy) wait() {
+	if delay := p.limiter.Reserve(time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+	if p.inFlight != nil {
+		p.inFlight <- struct{}{}
+	}
+}
+
+// done releases the in-flight slot taken by wait
+func (p *hostPolicy) done() {
+	if p.inFlight != nil {
+		<-p.inFlight
+	}
 }
 
 // NewTickerScheduler ...
 func NewTickerScheduler(r repository.Jobs, e callback.Executor, c Config) *TickerScheduler {
-	return &TickerScheduler{
+	s := &TickerScheduler{
 		Config:           c,
 		jobs:             r,
 		callbackExecutor: e,
 		tickInterval:     DefaultTickInterval * time.Second,
-		HostContexts:     make(map[string]*HostContext),
+		hostContexts:     make(map[string]*HostContext),
+		claims:           make(map[string]bool),
+		now:              time.Now,
+	}
+	if c.Leases != nil {
+		s.elector = NewElector(c.Leases, c.NodeID, c.LeaseTTL)
 	}
+	return s
 }
 
 // Start ...
@@ -47,82 +139,550 @@ func (s *TickerScheduler) Start() error {
 		return fmt.Errorf("scheduler: scheduler already started")
 	}
 	s.ticker = time.NewTicker(s.tickInterval)
-	go s.tick()
+	s.quit = make(chan struct{})
+	go s.tick(s.ticker, s.quit)
+	go s.renewClaims(s.quit)
+	atomic.StoreInt32(&s.running, 1)
 	return nil
 }
 
-// Stop ...
+// Stop stops the ticker, no more jobs are published to the host queues
 func (s *TickerScheduler) Stop() error {
 	if s.ticker == nil {
 		return fmt.Errorf("scheduler: scheduler wasn't started, cannot stop")
 	}
 	s.ticker.Stop()
+	close(s.quit)
+	s.ticker = nil
+	atomic.StoreInt32(&s.running, 0)
 	return nil
 }
 
-func (s *TickerScheduler) tick() {
-	for now := range s.ticker.C {
-		jobs, err := s.jobs.ListBySchedule(now.Unix())
-		if err != nil {
-			log.Printf("scheduler: error retrieving job list scheduled at %v: %v", now, err)
-			continue
+// Shutdown stops the scheduler and waits up to the grace period for the in-flight callbacks to
+// finish. Jobs still in the host queues are deferred in the repository, so they remain pending
+// and run after a restart.
+func (s *TickerScheduler) Shutdown(grace time.Duration) error {
+	if s.ticker != nil {
+		s.Stop()
+	}
+	if s.elector != nil {
+		s.elector.Release()
+	}
+
+	s.hostsMutex.Lock()
+	s.stopped = true
+	contexts := s.hostContexts
+	s.hostContexts = make(map[string]*HostContext)
+	s.hostsMutex.Unlock()
+
+	for _, context := range contexts {
+		atomic.StoreInt32(&context.stopping, 1)
+		close(context.queue)
+		for c := range context.queue {
+			s.drop(c)
 		}
+	}
 
-		if len(jobs) > 0 {
-			log.Printf("scheduler: launching %d callbacks scheduled at %v (%v)", len(jobs), now.Unix(), now)
-			go s.publish(jobs)
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.Info("shutdown complete")
+		return nil
+	case <-time.After(grace):
+		return fmt.Errorf("scheduler: grace period of %v expired with callbacks in flight", grace)
+	}
+}
+
+func (s *TickerScheduler) tick(ticker *time.Ticker, quit chan struct{}) {
+	for {
+		select {
+		case now := <-ticker.C:
+			s.tickAt(now)
+		case <-quit:
+			return
 		}
 	}
 }
 
+// tickAt publishes the jobs scheduled up to the given time. Nodes coordinated by a leader lease
+// only publish while leading, including the seconds missed since the last published one.
+func (s *TickerScheduler) tickAt(now time.Time) {
+	atomic.StoreInt64(&s.lastTick, now.UnixNano())
+	if s.Config.HostIdleTimeout > 0 {
+		defer s.evictIdle(now.Add(-s.Config.HostIdleTimeout))
+	}
+	if s.elector == nil {
+		s.publishAt(now.Unix(), now)
+		return
+	}
+
+	lease, leader := s.elector.Acquire(now)
+	if !leader {
+		return
+	}
+	from := lease.Checkpoint + 1
+	if lease.Checkpoint == 0 {
+		from = now.Unix()
+	}
+	for timestamp := from; timestamp <= now.Unix(); timestamp++ {
+		if !s.elector.Advance(timestamp, now) {
+			return
+		}
+		s.publishAt(timestamp, now)
+	}
+}
+
+// publishAt claims and publishes the jobs scheduled at the given timestamp, along with the ones
+// whose claim expired
+func (s *TickerScheduler) publishAt(timestamp int64, now time.Time) {
+	jobs, err := s.jobs.ClaimDue(timestamp, s.Config.NodeID, now, s.Config.claimTTL())
+	if err != nil {
+		logger.Error("error claiming jobs", "scheduled_at", timestamp, logging.KeyError, err)
+		return
+	}
+
+	s.claimsMutex.Lock()
+	for _, job := range jobs {
+		s.claims[job.ID] = true
+	}
+	s.claimsMutex.Unlock()
+
+	if len(jobs) > 0 {
+		logger.Info("launching callbacks", "count", len(jobs), "scheduled_at", timestamp)
+		go s.publish(jobs)
+	}
+}
+
 func (s *TickerScheduler) publish(jobs []entity.Job) {
+	pauses := s.pauses()
 	for _, job := range jobs {
 		if !job.IsExecutable() {
 			continue
 		}
+		if pause := matchPause(pauses, &job); pause != nil {
+			s.hold(job, pause)
+			continue
+		}
 
-		context, err := s.context(job)
-		if err != nil {
-			log.Printf("scheduler: error publishing job callback: %v", err)
+		err := s.enqueue(call{job: job})
+		if err == ErrHostQueueFull || err == ErrSchedulerStopped {
+			logger.Warn("deferring callback", logging.KeyJobID, job.ID, logging.KeyClientKey, job.ClientKey, logging.KeyError, err)
+			s.deferJob(job, s.now())
+		} else if err != nil {
+			logger.Error("error publishing job callback", logging.KeyJobID, job.ID, logging.KeyClientKey, job.ClientKey, logging.KeyError, err)
 		}
-		context.C <- job
 	}
 }
 
-func (s *TickerScheduler) context(job entity.Job) (*HostContext, error) {
-	url, err := url.ParseRequestURI(job.CallbackURL)
+// pauses returns the pauses of clients and hosts in effect, none if they can't be read
+func (s *TickerScheduler) pauses() []entity.Pause {
+	if s.Config.Pauses == nil {
+		return nil
+	}
+	pauses, err := s.Config.Pauses.List()
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve host context, error parsing callback URL: %v", err)
+		logger.Error("error listing pauses", logging.KeyError, err)
+	}
+	return pauses
+}
+
+func matchPause(pauses []entity.Pause, job *entity.Job) *entity.Pause {
+	for i := range pauses {
+		if pauses[i].Matches(job) {
+			return &pauses[i]
+		}
+	}
+	return nil
+}
+
+// hold pauses a job due while its client or host is paused, instead of sending its callback,
+// until it's resumed along with them
+func (s *TickerScheduler) hold(job entity.Job, pause *entity.Pause) {
+	s.release(job)
+	if _, err := s.jobs.Pause(job.ID, s.now()); err != nil {
+		jobLogger(job).Error("error holding job", "paused_client", pause.ClientKey, "paused_host", pause.CallbackHost, logging.KeyError, err)
+		return
+	}
+	jobLogger(job).Info("job held", "paused_client", pause.ClientKey, "paused_host", pause.CallbackHost)
+}
+
+// enqueue adds the call to the queue of its host without blocking
+func (s *TickerScheduler) enqueue(c call) error {
+	url, err := url.ParseRequestURI(c.job.CallbackURL)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve host context, error parsing callback URL: %v", err)
+	}
+
+	s.hostsMutex.Lock()
+	defer s.hostsMutex.Unlock()
+
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	context := s.context(url.Host)
+	context.LastUsed = s.now()
+	c.span = s.Config.Tracer.StartFromTraceparent(c.job.TraceParent, "scheduler.dispatch")
+	c.span.SetAttribute("job.id", c.job.ID)
+	c.span.SetAttribute("callback.host", url.Host)
+	if c.job.Claim != nil {
+		c.span.SetAttribute("execution.id", c.job.Claim.ExecutionID)
+	}
+	c.queued = s.Config.Tracer.StartWithParent(c.span.Context(), "scheduler.queue_wait")
+	select {
+	case context.queue <- c:
+		return nil
+	default:
+		c.queued.End()
+		c.span.RecordError(ErrHostQueueFull)
+		c.span.End()
+		return ErrHostQueueFull
+	}
+}
+
+// Run executes the job callback right away through its host queue, regardless of the job
+// schedule, and records the execution with the manual trigger. It blocks until the callback is
+// executed.
+func (s *TickerScheduler) Run(job entity.Job) (entity.JobExecution, error) {
+	result := make(chan runResult, 1)
+	if err := s.enqueue(call{job: ManualRun(job, s.Config.NodeID, s.now()), result: result}); err != nil {
+		return entity.JobExecution{}, err
+	}
+	r := <-result
+	return r.execution, r.err
+}
+
+// ManualRun returns the job with a claim identifying a manual execution
+func ManualRun(job entity.Job, node string, now time.Time) entity.Job {
+	job.Claim = &entity.JobClaim{ExecutionID: fmt.Sprintf("%s-manual-%d", job.ID, now.UnixNano()), Owner: node}
+	return job
+}
+
+// context returns the context of the given host, creating it when it doesn't exist. The caller
+// must hold the hostsMutex write lock.
+func (s *TickerScheduler) context(host string) *HostContext {
+	if context, exists := s.hostContexts[host]; exists {
+		return context
+	}
+
+	queueSize := s.Config.HostQueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultHostQueueSize
 	}
+	limits := s.Config.LimitsFor(host)
+	context := &HostContext{
+		Host:     host,
+		queue:    make(chan call, queueSize),
+		LastUsed: s.now(),
+		Limits:   limits,
+		Breaker:  NewBreaker(s.Config.Breaker),
+	}
+	context.enforcer.Store(newHostPolicy(limits, s.Config.Allowed(host)))
+	s.resize(context, s.Config.WorkersPerHost)
+	s.hostContexts[host] = context
+	logger.Info("host context created", logging.KeyHost, host, "workers", context.Workers, "queue_size", queueSize, "limits", limits)
+	return context
+}
 
-	if _, exists := s.HostContexts[url.Host]; !exists {
-		s.HostContexts[url.Host] = &HostContext{
-			Host:     url.Host,
-			C:        make(chan entity.Job, 1000), // TODO think better about the host channel buffer size
-			LastUsed: time.Now(),
-			Workers:  s.Config.WorkersPerHost,
+// resize starts or stops workers of the host until it has the given number of them. Stopped
+// workers finish their current callback first. The caller must hold the hostsMutex write lock.
+func (s *TickerScheduler) resize(context *HostContext, workers int) {
+	for len(context.stops) < workers {
+		stop := make(chan struct{})
+		context.stops = append(context.stops, stop)
+		s.workers.Add(1)
+		go s.handle(context, stop)
+	}
+	for len(context.stops) > workers && len(context.stops) > 0 {
+		last := len(context.stops) - 1
+		close(context.stops[last])
+		context.stops = context.stops[:last]
+	}
+	context.Workers = len(context.stops)
+}
+
+// Reload applies the runtime configuration. Existing hosts get the new limits, access rules and
+// number of workers right away, keeping their queued callbacks.
+func (s *TickerScheduler) Reload(c RuntimeConfig) {
+	s.hostsMutex.Lock()
+	defer s.hostsMutex.Unlock()
+
+	s.Config.WorkersPerHost = c.WorkersPerHost
+	s.Config.HostLimits = c.HostLimits
+	s.Config.HostLimitRules = c.HostLimitRules
+	s.Config.AllowHosts = c.AllowHosts
+	s.Config.DenyHosts = c.DenyHosts
+	if s.stopped {
+		return
+	}
+	for host, context := range s.hostContexts {
+		limits, allowed := s.Config.LimitsFor(host), s.Config.Allowed(host)
+		if limits != context.Limits || allowed != context.policy().allowed {
+			context.Limits = limits
+			context.enforcer.Store(newHostPolicy(limits, allowed))
 		}
-		for i := 0; i < s.HostContexts[url.Host].Workers; i++ {
-			go s.handle(s.HostContexts[url.Host])
+		s.resize(context, c.WorkersPerHost)
+	}
+	logger.Info("scheduler configuration reloaded", "workers", c.WorkersPerHost, "hosts", len(s.hostContexts))
+}
+
+// evictIdle removes the contexts of the hosts idle since the given time, stopping their workers
+func (s *TickerScheduler) evictIdle(since time.Time) {
+	s.hostsMutex.Lock()
+	defer s.hostsMutex.Unlock()
+
+	for host, context := range s.hostContexts {
+		if context.idle(since) {
+			delete(s.hostContexts, host)
+			close(context.queue)
+			logger.Info("host context evicted", logging.KeyHost, host, "idle_since", context.LastUsed)
 		}
-		log.Printf("scheduler: host context for %s created with %d workers", s.HostContexts[url.Host].Host, s.HostContexts[url.Host].Workers)
 	}
-	return s.HostContexts[url.Host], nil
 }
 
-func (s *TickerScheduler) handle(context *HostContext) {
-	for job := range context.C {
-		var newStatus string
-		var errMessage string
-		if err := s.callbackExecutor.Execute(job); err == nil {
-			newStatus = entity.JobStatusSuccess
+// handle executes the queued callbacks of the host until its queue is closed or the worker is
+// stopped
+func (s *TickerScheduler) handle(context *HostContext, stop chan struct{}) {
+	defer s.workers.Done()
+	for {
+		var c call
+		select {
+		case next, open := <-context.queue:
+			if !open {
+				return
+			}
+			c = next
+		case <-stop:
+			return
+		}
+		c.queued.End()
+		if atomic.LoadInt32(&context.stopping) == 1 {
+			s.drop(c)
+			continue
+		}
+		atomic.AddInt32(&context.active, 1)
+		if c.result != nil {
+			s.run(context, c)
 		} else {
-			newStatus = entity.JobStatusError
-			errMessage = fmt.Sprintf("%v", err)
-			log.Printf("scheduler: job[ID:%s]: error executing callback: %v", job.ID, err)
+			s.execute(context, c)
 		}
-		if _, err := s.jobs.AddExecution(job.ID, time.Now(), newStatus, errMessage); err != nil {
-			log.Printf("scheduler: job[ID:%s]: error adding execution with status '%s': %v", job.ID, newStatus, err)
+		c.span.End()
+		atomic.AddInt32(&context.active, -1)
+	}
+}
+
+// drop gives up a queued call on shutdown, deferring scheduled jobs so they remain pending
+func (s *TickerScheduler) drop(c call) {
+	c.queued.End()
+	c.span.RecordError(ErrSchedulerStopped)
+	c.span.End()
+	if c.result != nil {
+		c.result <- runResult{err: ErrSchedulerStopped}
+		return
+	}
+	s.deferJob(c.job, s.now())
+}
+
+// run executes a manual call, leaving the job status and schedule untouched
+func (s *TickerScheduler) run(context *HostContext, c call) {
+	if allowed, retryAt := context.Breaker.Allow(s.now()); !allowed {
+		err := &BreakerOpenError{Host: context.Host, RetryAt: retryAt}
+		c.span.RecordError(err)
+		c.result <- runResult{err: err}
+		return
+	}
+	status, message := s.callback(context, c.job, c.span)
+	execution := entity.JobExecution{Timestamp: s.now().Unix(), Status: status, Message: message, Trigger: entity.JobTriggerManual}
+	if _, err := s.jobs.AppendExecution(c.job.ID, execution); err != nil {
+		jobLogger(c.job).Error("error adding manual execution", logging.KeyError, err)
+	}
+	c.result <- runResult{execution: execution}
+}
+
+func (s *TickerScheduler) execute(context *HostContext, c call) {
+	job := c.job
+	if s.claimLost(job) || !s.renewClaim(job) {
+		jobLogger(job).Warn("claim lost, execution skipped")
+		c.span.RecordError(repository.ErrClaimLost)
+		return
+	}
+	if allowed, retryAt := context.Breaker.Allow(s.now()); !allowed {
+		c.span.RecordError(&BreakerOpenError{Host: context.Host, RetryAt: retryAt})
+		s.deferJob(job, retryAt)
+		return
+	}
+	if job.NextRun > 0 {
+		lag := s.now().Sub(time.Unix(job.NextRun, 0))
+		atomic.StoreInt64(&s.tickLag, int64(lag))
+		s.Config.recorder().TickLag(lag)
+	}
+
+	newStatus, errMessage := s.callback(context, job, c.span)
+	c.span.SetAttribute("job.status", newStatus)
+	s.release(job)
+	if _, err := s.jobs.Complete(job.ID, s.Config.NodeID, s.now(), newStatus, errMessage); err != nil {
+		jobLogger(job).Error("error completing execution", logging.KeyStatus, newStatus, logging.KeyError, err)
+	}
+}
+
+// callback sends the job callback within the host limits, returning the execution status and
+// error message. The callback request propagates its span, child of the dispatch one.
+func (s *TickerScheduler) callback(context *HostContext, job entity.Job, dispatch *tracing.Span) (string, string) {
+	policy := context.policy()
+	if !policy.allowed {
+		// the breaker allowed the callback, as the host may be allowed again by a reload
+		context.Breaker.Cancel()
+		dispatch.RecordError(ErrHostNotAllowed)
+		jobLogger(job).Warn("callback rejected", logging.KeyHost, context.Host, logging.KeyError, ErrHostNotAllowed)
+		return entity.JobStatusError, ErrHostNotAllowed.Error()
+	}
+	policy.wait()
+	span := s.Config.Tracer.StartWithParent(dispatch.Context(), "callback")
+	span.SetAttribute("http.url", job.CallbackURL)
+	job.TraceParent = span.Traceparent()
+	if s.Config.Events != nil {
+		s.Config.Events.Publish(events.JobEvent(events.JobDispatched, &job, ""))
+	}
+	start := time.Now()
+	err := s.callbackExecutor.Execute(job)
+	latency := time.Since(start)
+	span.RecordError(err)
+	span.End()
+	policy.done()
+	if state := context.Breaker.Record(err == nil, s.now()); state != "" {
+		logger.Warn("circuit breaker state changed", logging.KeyHost, context.Host, "state", state)
+	}
+	if err != nil {
+		jobLogger(job).Warn("callback failed", logging.KeyHost, context.Host, logging.KeyStatus, entity.JobStatusError,
+			logging.KeyLatency, latency, logging.KeyError, err)
+		return entity.JobStatusError, fmt.Sprintf("%v", err)
+	}
+	jobLogger(job).Info("callback executed", logging.KeyHost, context.Host, logging.KeyStatus, entity.JobStatusSuccess,
+		logging.KeyLatency, latency)
+	return entity.JobStatusSuccess, ""
+}
+
+// jobLogger returns a logger whose records have the job fields, including the attempt of the
+// run claimed
+func jobLogger(job entity.Job) *slog.Logger {
+	l := logger.With(logging.KeyJobID, job.ID, logging.KeyClientKey, job.ClientKey)
+	if job.Claim != nil {
+		l = l.With("execution_id", job.Claim.ExecutionID, logging.KeyAttempt, job.Claim.Attempt)
+	}
+	return l
+}
+
+// claimLost returns whether the job was claimed by the scheduler but the claim couldn't be renewed
+func (s *TickerScheduler) claimLost(job entity.Job) bool {
+	s.claimsMutex.Lock()
+	defer s.claimsMutex.Unlock()
+	return job.Claim != nil && !s.claims[job.ID]
+}
+
+// renewClaim renews the claim on the job right before its callback, so jobs paused or taken over
+// after being published aren't executed. It returns false when the claim was lost.
+func (s *TickerScheduler) renewClaim(job entity.Job) bool {
+	if job.Claim == nil {
+		return true
+	}
+	_, err := s.jobs.Heartbeat(job.ID, s.Config.NodeID, s.now(), s.Config.claimTTL())
+	if err == repository.ErrClaimLost {
+		s.release(job)
+		return false
+	}
+	if err != nil {
+		jobLogger(job).Error("error renewing claim", logging.KeyError, err)
+	}
+	return true
+}
+
+// release stops renewing the claim on the job
+func (s *TickerScheduler) release(job entity.Job) {
+	s.claimsMutex.Lock()
+	defer s.claimsMutex.Unlock()
+	delete(s.claims, job.ID)
+}
+
+// renewClaims periodically renews the claims on the jobs published and not completed yet
+func (s *TickerScheduler) renewClaims(quit chan struct{}) {
+	ticker := time.NewTicker(s.Config.claimTTL() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.renewClaimsAt(now)
+		case <-quit:
+			return
 		}
 	}
 }
+
+func (s *TickerScheduler) renewClaimsAt(now time.Time) {
+	s.claimsMutex.Lock()
+	ids := make([]string, 0, len(s.claims))
+	for id := range s.claims {
+		ids = append(ids, id)
+	}
+	s.claimsMutex.Unlock()
+
+	for _, id := range ids {
+		_, err := s.jobs.Heartbeat(id, s.Config.NodeID, now, s.Config.claimTTL())
+		if err == repository.ErrClaimLost {
+			logger.Warn("claim lost", logging.KeyJobID, id)
+			s.release(entity.Job{ID: id})
+		} else if err != nil {
+			logger.Error("error renewing claim", logging.KeyJobID, id, logging.KeyError, err)
+		}
+	}
+}
+
+// deferJob reschedules the job to the first second after the given time
+func (s *TickerScheduler) deferJob(job entity.Job, until time.Time) {
+	s.release(job)
+	timestamp := until.Unix()
+	if until.Nanosecond() > 0 {
+		timestamp++
+	}
+	if _, err := s.jobs.Reschedule(job.ID, timestamp); err != nil {
+		jobLogger(job).Error("error deferring callback", "deferred_to", timestamp, logging.KeyError, err)
+	}
+}
+
+// Status returns the state of the scheduler loop
+func (s *TickerScheduler) Status() Status {
+	status := Status{
+		Running: atomic.LoadInt32(&s.running) == 1,
+		Leader:  s.elector == nil || s.elector.IsLeader(),
+		TickLag: time.Duration(atomic.LoadInt64(&s.tickLag)),
+	}
+	if lastTick := atomic.LoadInt64(&s.lastTick); lastTick > 0 {
+		status.LastTick = time.Unix(0, lastTick)
+	}
+	return status
+}
+
+// Hosts returns the status of every host context
+func (s *TickerScheduler) Hosts() []HostStatus {
+	s.hostsMutex.RLock()
+	defer s.hostsMutex.RUnlock()
+
+	hosts := make([]HostStatus, 0, len(s.hostContexts))
+	for _, context := range s.hostContexts {
+		hosts = append(hosts, HostStatus{
+			Host:        context.Host,
+			Workers:     context.Workers,
+			QueueLength: len(context.queue),
+			QueueSize:   cap(context.queue),
+			Busy:        int(atomic.LoadInt32(&context.active)),
+			LastUsed:    context.LastUsed,
+			Limits:      context.Limits,
+			Allowed:     context.policy().allowed,
+			Breaker:     context.Breaker.Status(),
+		})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
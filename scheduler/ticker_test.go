@@ -1,10 +1,16 @@
 package scheduler
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/marcoshack/schedula/entity"
+	"github.com/marcoshack/schedula/events"
+	"github.com/marcoshack/schedula/metrics"
+	"github.com/marcoshack/schedula/repository"
+	"github.com/marcoshack/schedula/tracing"
 )
 
 func createScheduler(t *testing.T) (Scheduler, *RepositoryMock) {
@@ -17,6 +23,366 @@ func createScheduler(t *testing.T) (Scheduler, *RepositoryMock) {
 	return s, r
 }
 
+func TestTickerScheduler_HostMaxInFlight(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &BlockingExecutorMock{release: make(chan bool)}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 5, HostLimits: HostLimits{MaxInFlight: 2}})
+
+	jobs := make([]entity.Job, 6)
+	for i := range jobs {
+		jobs[i] = entity.Job{ID: fmt.Sprintf("job-%d", i), CallbackURL: "http://example.com/callback"}
+	}
+	s.publish(jobs)
+
+	for i := 0; i < len(jobs); i++ {
+		time.Sleep(10 * time.Millisecond)
+		if n := e.InFlight(); n > 2 {
+			t.Fatalf("expected at most 2 callbacks in flight but got %d", n)
+		}
+		e.release <- true
+	}
+	waitFor(t, func() bool { return r.Counter("Complete") == len(jobs) })
+}
+
+func TestTickerScheduler_BreakerDefersJobs(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &FailingExecutorMock{}
+	s := NewTickerScheduler(r, e, Config{
+		WorkersPerHost: 1,
+		Breaker:        BreakerConfig{Window: 2, MinRequests: 2, FailureRate: 1, OpenDuration: time.Minute},
+	})
+
+	jobs := make([]entity.Job, 5)
+	for i := range jobs {
+		jobs[i] = entity.Job{ID: fmt.Sprintf("job-%d", i), CallbackURL: "http://example.com/callback"}
+	}
+	s.publish(jobs)
+
+	waitFor(t, func() bool { return r.Counter("Reschedule") == 3 })
+	if n := e.Counter("Execute"); n != 2 {
+		t.Fatalf("expected 2 callbacks before the breaker opened but got %d", n)
+	}
+	if state := s.Hosts()[0].Breaker.State; state != BreakerOpen {
+		t.Fatalf("expected host breaker to be open but it's %s", state)
+	}
+}
+
+func TestTickerScheduler_FullQueueDefersJobs(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &BlockingExecutorMock{release: make(chan bool)}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1, HostQueueSize: 2})
+
+	jobs := make([]entity.Job, 6)
+	for i := range jobs {
+		jobs[i] = entity.Job{ID: fmt.Sprintf("job-%d", i), CallbackURL: "http://example.com/callback"}
+	}
+	s.publish(jobs[:1])
+	waitFor(t, func() bool { return e.InFlight() == 1 })
+	s.publish(jobs[1:])
+
+	if n := r.Counter("Reschedule"); n != 3 {
+		t.Fatalf("expected 3 callbacks deferred but got %d", n)
+	}
+	for i := 0; i < 3; i++ {
+		e.release <- true
+	}
+	waitFor(t, func() bool { return r.Counter("Complete") == 3 })
+}
+
+func TestTickerScheduler_EvictIdleHosts(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &CallbackExecutorMock{}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 2})
+
+	s.publish([]entity.Job{
+		{ID: "job-1", CallbackURL: "http://a.example.com/callback"},
+		{ID: "job-2", CallbackURL: "http://b.example.com/callback"},
+	})
+	waitFor(t, func() bool { return r.Counter("Complete") == 2 })
+
+	s.evictIdle(time.Now().Add(-time.Hour))
+	if n := len(s.Hosts()); n != 2 {
+		t.Fatalf("expected 2 host contexts but got %d", n)
+	}
+
+	s.evictIdle(time.Now().Add(time.Second))
+	if n := len(s.Hosts()); n != 0 {
+		t.Fatalf("expected idle host contexts to be evicted but got %d", n)
+	}
+
+	s.publish([]entity.Job{{ID: "job-3", CallbackURL: "http://a.example.com/callback"}})
+	waitFor(t, func() bool { return r.Counter("Complete") == 3 })
+}
+
+func TestTickerScheduler_ShutdownDefersQueuedJobs(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &BlockingExecutorMock{release: make(chan bool)}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1})
+	s.Start()
+
+	jobs := make([]entity.Job, 4)
+	for i := range jobs {
+		jobs[i] = entity.Job{ID: fmt.Sprintf("job-%d", i), CallbackURL: "http://example.com/callback"}
+	}
+	s.publish(jobs)
+	waitFor(t, func() bool { return e.InFlight() == 1 })
+
+	done := make(chan error)
+	go func() { done <- s.Shutdown(time.Second) }()
+	waitFor(t, func() bool { return r.Counter("Reschedule") == 3 })
+	e.release <- true
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error shutting down scheduler: %v", err)
+	}
+	if n := r.Counter("Complete"); n != 1 {
+		t.Fatalf("expected the in-flight callback to finish but got %d executions", n)
+	}
+
+	s.publish(jobs[:1])
+	if n := r.Counter("Reschedule"); n != 4 {
+		t.Fatalf("expected jobs published after shutdown to be deferred but got %d", n)
+	}
+}
+
+func TestTickerScheduler_ShutdownGracePeriod(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &BlockingExecutorMock{release: make(chan bool, 1)}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1})
+
+	s.publish([]entity.Job{{ID: "job-1", CallbackURL: "http://example.com/callback"}})
+	waitFor(t, func() bool { return e.InFlight() == 1 })
+	if err := s.Shutdown(10 * time.Millisecond); err == nil {
+		t.Fatalf("expected grace period to expire with a callback in flight")
+	}
+	e.release <- true
+}
+
+func TestTickerScheduler_Run(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &CallbackExecutorMock{}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1})
+
+	execution, err := s.Run(entity.Job{ID: "job-1", CallbackURL: "http://example.com/callback"})
+	if err != nil {
+		t.Fatalf("unexpected error running job: %v", err)
+	}
+	if execution.Status != entity.JobStatusSuccess || execution.Trigger != entity.JobTriggerManual {
+		t.Fatalf("expected successful manual execution but got %+v", execution)
+	}
+	if e.Counter("Execute") != 1 || r.Counter("AppendExecution") != 1 {
+		t.Fatalf("expected the callback to be executed and recorded once")
+	}
+	if r.Counter("Complete") != 0 || r.Counter("Reschedule") != 0 {
+		t.Fatalf("expected manual run not to change the job schedule")
+	}
+}
+
+func TestTickerScheduler_RunWithBreakerOpen(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &FailingExecutorMock{}
+	s := NewTickerScheduler(r, e, Config{
+		WorkersPerHost: 1,
+		Breaker:        BreakerConfig{Window: 1, MinRequests: 1, FailureRate: 1, OpenDuration: time.Minute},
+	})
+	job := entity.Job{ID: "job-1", CallbackURL: "http://example.com/callback"}
+
+	if execution, _ := s.Run(job); execution.Status != entity.JobStatusError {
+		t.Fatalf("expected failed execution but got %+v", execution)
+	}
+	if _, err := s.Run(job); err == nil {
+		t.Fatalf("expected an error running a job with the host circuit breaker open")
+	} else if _, ok := err.(*BreakerOpenError); !ok {
+		t.Fatalf("expected a BreakerOpenError but got %v", err)
+	}
+}
+
+func TestTickerScheduler_RunDeniedHostReleasesBreakerProbe(t *testing.T) {
+	r := &RepositoryMock{}
+	s := NewTickerScheduler(r, &FailingExecutorMock{}, Config{
+		WorkersPerHost: 1,
+		Breaker:        BreakerConfig{Window: 1, MinRequests: 1, FailureRate: 1, OpenDuration: time.Minute},
+	})
+	now := time.Unix(1438948984, 0)
+	s.now = func() time.Time { return now }
+	job := entity.Job{ID: "job-1", CallbackURL: "http://example.com/callback"}
+	s.Run(job)
+
+	// the probe let through once the breaker cools down is rejected by the deny list
+	now = now.Add(time.Minute)
+	s.Reload(RuntimeConfig{WorkersPerHost: 1, DenyHosts: []string{"example.com"}})
+	if execution, err := s.Run(job); err != nil || execution.Message != ErrHostNotAllowed.Error() {
+		t.Fatalf("expected the callback to be rejected but got %+v (%v)", execution, err)
+	}
+
+	s.Reload(RuntimeConfig{WorkersPerHost: 1})
+	if _, err := s.Run(job); err != nil {
+		t.Fatalf("expected the host to be probed again once allowed but got %v", err)
+	}
+}
+
+func TestTickerScheduler_TickLag(t *testing.T) {
+	r := &RepositoryMock{}
+	m := &TickLagRecorderMock{}
+	s := NewTickerScheduler(r, &CallbackExecutorMock{}, Config{WorkersPerHost: 1, Metrics: m})
+	now := time.Unix(1438948984, 500000000)
+	s.now = func() time.Time { return now }
+
+	s.publish([]entity.Job{{ID: "job-1", CallbackURL: "http://example.com/callback", NextRun: now.Unix() - 2}})
+
+	waitFor(t, func() bool { return r.Counter("Complete") == 1 })
+	if lags := m.Lags(); len(lags) != 1 || lags[0] != 2500*time.Millisecond {
+		t.Fatalf("expected a tick lag of 2.5s but got %v", lags)
+	}
+	if lag := s.Status().TickLag; lag != 2500*time.Millisecond {
+		t.Fatalf("expected status tick lag of 2.5s but got %v", lag)
+	}
+}
+
+func TestTickerScheduler_Status(t *testing.T) {
+	s := NewTickerScheduler(&RepositoryMock{}, &CallbackExecutorMock{}, Config{})
+	if status := s.Status(); status.Running || !status.LastTick.IsZero() {
+		t.Fatalf("expected scheduler not started but got %+v", status)
+	}
+
+	now := time.Unix(1438948984, 0)
+	s.tickAt(now)
+	s.Start()
+	defer s.Stop()
+	if status := s.Status(); !status.Running || !status.Leader || !status.LastTick.Equal(now) {
+		t.Fatalf("expected running leader that ticked at %v but got %+v", now, status)
+	}
+}
+
+func TestTickerScheduler_TracesDispatch(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &TraceparentRecorderMock{}
+	exporter := &tracing.InMemoryExporter{}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1, Tracer: tracing.NewTracer(exporter)})
+	creation := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	s.publish([]entity.Job{{ID: "job-1", CallbackURL: "http://example.com/callback", TraceParent: creation}})
+
+	waitFor(t, func() bool { _, ok := exporter.Find("scheduler.dispatch"); return ok })
+	dispatch, _ := exporter.Find("scheduler.dispatch")
+	if dispatch.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || dispatch.ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("expected dispatch span to be a child of the job creation but got %+v", dispatch)
+	}
+	for _, name := range []string{"scheduler.queue_wait", "callback"} {
+		if span, ok := exporter.Find(name); !ok || span.ParentSpanID != dispatch.SpanID {
+			t.Fatalf("expected %s span to be a child of the dispatch span but got %+v", name, span)
+		}
+	}
+	callback, _ := exporter.Find("callback")
+	if got := e.Traceparents(); len(got) != 1 || got[0] != callback.Context.Traceparent() {
+		t.Fatalf("expected the callback span to be propagated but got %v", got)
+	}
+}
+
+func TestTickerScheduler_PublishesDispatchedEvents(t *testing.T) {
+	bus := events.NewBus(10)
+	subscription := bus.Subscribe(events.Filter{Type: events.JobDispatched}, 0)
+	s := NewTickerScheduler(&RepositoryMock{}, &CallbackExecutorMock{}, Config{WorkersPerHost: 1, Events: bus})
+
+	if _, err := s.Run(entity.Job{ID: "job-1", ClientKey: "acme", CallbackURL: "http://example.com/callback"}); err != nil {
+		t.Fatalf("unexpected error running job: %v", err)
+	}
+	if e := <-subscription.Events(); e.JobID != "job-1" || e.ClientKey != "acme" {
+		t.Fatalf("expected dispatched event of job-1 but got %+v", e)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for condition")
+}
+
+func TestTickerScheduler_ReloadResizesWorkers(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &BlockingExecutorMock{release: make(chan bool)}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1})
+
+	jobs := make([]entity.Job, 4)
+	for i := range jobs {
+		jobs[i] = entity.Job{ID: fmt.Sprintf("job-%d", i), CallbackURL: "http://example.com/callback"}
+	}
+	s.publish(jobs)
+	waitFor(t, func() bool { return e.InFlight() == 1 })
+
+	s.Reload(RuntimeConfig{WorkersPerHost: 3})
+	waitFor(t, func() bool { return e.InFlight() == 3 })
+	if workers := s.Hosts()[0].Workers; workers != 3 {
+		t.Fatalf("expected host to have 3 workers but got %d", workers)
+	}
+
+	s.Reload(RuntimeConfig{WorkersPerHost: 1})
+	for i := 0; i < len(jobs); i++ {
+		e.release <- true
+		time.Sleep(10 * time.Millisecond)
+		if n := e.InFlight(); i >= 2 && n > 1 {
+			t.Fatalf("expected a single callback in flight after shrinking but got %d", n)
+		}
+	}
+	waitFor(t, func() bool { return r.Counter("Complete") == len(jobs) })
+	if workers := s.Hosts()[0].Workers; workers != 1 {
+		t.Fatalf("expected host to have 1 worker but got %d", workers)
+	}
+}
+
+func TestTickerScheduler_ReloadHostRules(t *testing.T) {
+	r := &RepositoryMock{}
+	e := &CallbackExecutorMock{}
+	s := NewTickerScheduler(r, e, Config{WorkersPerHost: 1, DenyHosts: []string{"*.example.com"}})
+
+	s.publish([]entity.Job{{ID: "job-1", CallbackURL: "http://api.example.com/callback"}})
+	waitFor(t, func() bool { return r.Counter("Complete") == 1 })
+	if n := e.Counter("Execute"); n != 0 {
+		t.Fatalf("expected callback to denied host not to be sent but got %d", n)
+	}
+	if host := s.Hosts()[0]; host.Allowed {
+		t.Fatalf("expected host to be denied: %+v", host)
+	}
+
+	s.Reload(RuntimeConfig{WorkersPerHost: 1, HostLimits: HostLimits{MaxInFlight: 1}})
+	s.publish([]entity.Job{{ID: "job-2", CallbackURL: "http://api.example.com/callback"}})
+	waitFor(t, func() bool { return r.Counter("Complete") == 2 })
+	if n := e.Counter("Execute"); n != 1 {
+		t.Fatalf("expected callback to be sent after reload but got %d", n)
+	}
+	if host := s.Hosts()[0]; !host.Allowed || host.Limits.MaxInFlight != 1 {
+		t.Fatalf("expected reloaded host limits and access: %+v", host)
+	}
+}
+
+func TestTickerScheduler_HoldsJobsOfPausedHosts(t *testing.T) {
+	repo, _ := repository.New("in-memory")
+	pauses, _ := repository.NewPauses("in-memory")
+	e := &CallbackExecutorMock{}
+	s := NewTickerScheduler(repo, e, Config{WorkersPerHost: 1, Pauses: pauses})
+	pauses.Add(entity.Pause{CallbackHost: "paused.example.com", PausedAt: 1234567880})
+
+	// the jobs are created after the pause
+	schedule := entity.JobSchedule{Format: entity.ScheduleFormatTimestamp, Value: "1234567890"}
+	held, _ := repo.Add(entity.Job{CallbackURL: "http://paused.example.com/callback", Schedule: schedule})
+	sent, _ := repo.Add(entity.Job{CallbackURL: "http://example.com/callback", Schedule: schedule})
+	s.publishAt(1234567890, time.Unix(1234567890, 0))
+
+	waitFor(t, func() bool {
+		job, _ := repo.Get(sent.ID)
+		return job.Status == entity.JobStatusSuccess
+	})
+	if job, _ := repo.Get(held.ID); job.Status != entity.JobStatusPaused || job.Claim != nil {
+		t.Fatalf("expected job of the paused host to be held but got %+v", job)
+	}
+	if n := e.Counter("Execute"); n != 1 {
+		t.Fatalf("expected only the callback of the other host but got %d", n)
+	}
+}
+
 func assertReposityCall(method string, count int, r *RepositoryMock, t *testing.T) {
 	if r.Counter(method) != 1 {
 		t.Fatalf("expected 1 call to repository but got %d", r.Counter(method))
@@ -27,15 +393,20 @@ func assertReposityCall(method string, count int, r *RepositoryMock, t *testing.
 // TODO replace with a mock framework like golang/mock
 //
 type CounterMock struct {
+	sync.Mutex
 	counters map[string]int
 }
 
 func (m *CounterMock) Counter(method string) int {
+	m.Lock()
+	defer m.Unlock()
 	m.assertInit()
 	return m.counters[method]
 }
 
 func (m *CounterMock) Inc(method string) {
+	m.Lock()
+	defer m.Unlock()
 	m.assertInit()
 	m.counters[method]++
 }
@@ -50,28 +421,29 @@ type RepositoryMock struct {
 	CounterMock
 }
 
-func (r *RepositoryMock) Counter(method string) int {
-	return r.counters[method]
-}
-
 func (r *RepositoryMock) Add(job entity.Job) (entity.Job, error) {
 	r.Inc("Add")
 	return job, nil
 }
 
+func (r *RepositoryMock) AddAll(jobs []entity.Job) ([]entity.Job, []error) {
+	r.Inc("AddAll")
+	return jobs, make([]error, len(jobs))
+}
+
 func (r *RepositoryMock) Get(id string) (entity.Job, error) {
 	r.Inc("Get")
 	return entity.Job{ID: id}, nil
 }
 
-func (r *RepositoryMock) List(skip int, limit int) ([]entity.Job, error) {
+func (r *RepositoryMock) List(query repository.JobQuery) (repository.JobPage, error) {
 	r.Inc("List")
-	return make([]entity.Job, 0), nil
+	return repository.JobPage{Jobs: make([]entity.Job, 0)}, nil
 }
 
-func (r *RepositoryMock) Count() int {
+func (r *RepositoryMock) Count(filter repository.JobFilter) int {
 	r.Inc("Count")
-	return r.counters["Count"]
+	return r.Counter("Count")
 }
 
 func (r *RepositoryMock) ListBySchedule(timestamp int64) ([]entity.Job, error) {
@@ -88,11 +460,56 @@ func (r *RepositoryMock) Cancel(jobID string) (entity.Job, error) {
 	return entity.Job{ID: jobID}, nil
 }
 
+func (r *RepositoryMock) Reschedule(jobID string, timestamp int64) (entity.Job, error) {
+	r.Inc("Reschedule")
+	return entity.Job{ID: jobID, NextRun: timestamp}, nil
+}
+
 func (r *RepositoryMock) UpdateStatus(jobID string, status string) (entity.Job, error) {
 	r.Inc("SetStatus")
 	return entity.Job{ID: jobID}, nil
 }
 
+func (r *RepositoryMock) Pause(jobID string, now time.Time) (entity.Job, error) {
+	r.Inc("Pause")
+	return entity.Job{ID: jobID, Status: entity.JobStatusPaused}, nil
+}
+
+func (r *RepositoryMock) Resume(jobID string, missed string, now time.Time) (entity.Job, error) {
+	r.Inc("Resume")
+	return entity.Job{ID: jobID, Status: entity.JobStatusPending}, nil
+}
+
+func (r *RepositoryMock) ClaimDue(timestamp int64, owner string, now time.Time, ttl time.Duration) ([]entity.Job, error) {
+	r.Inc("ClaimDue")
+	return make([]entity.Job, 0), nil
+}
+
+func (r *RepositoryMock) Heartbeat(jobID string, owner string, now time.Time, ttl time.Duration) (entity.Job, error) {
+	r.Inc("Heartbeat")
+	return entity.Job{ID: jobID}, nil
+}
+
+func (r *RepositoryMock) Complete(jobID string, owner string, date time.Time, status string, message string) (entity.Job, error) {
+	r.Inc("Complete")
+	return entity.Job{ID: jobID}, nil
+}
+
+func (r *RepositoryMock) AppendExecution(jobID string, execution entity.JobExecution) (entity.Job, error) {
+	r.Inc("AppendExecution")
+	return entity.Job{ID: jobID}, nil
+}
+
+func (r *RepositoryMock) ListExecutions(query repository.ExecutionQuery) (repository.ExecutionPage, error) {
+	r.Inc("ListExecutions")
+	return repository.ExecutionPage{Executions: make([]entity.JobExecution, 0)}, nil
+}
+
+func (r *RepositoryMock) Ping() error {
+	r.Inc("Ping")
+	return nil
+}
+
 func (r *RepositoryMock) AddExecution(jobID string, date time.Time, status string, message string) (entity.Job, error) {
 	r.Inc("AddExecution")
 	return entity.Job{ID: jobID}, nil
@@ -104,5 +521,74 @@ type CallbackExecutorMock struct {
 
 func (e *CallbackExecutorMock) Execute(job entity.Job) error {
 	e.Inc("Execute")
+	e.Inc(job.ID)
 	return nil
 }
+
+type BlockingExecutorMock struct {
+	sync.Mutex
+	inFlight int
+	release  chan bool
+}
+
+func (e *BlockingExecutorMock) Execute(job entity.Job) error {
+	e.Lock()
+	e.inFlight++
+	e.Unlock()
+	<-e.release
+	e.Lock()
+	e.inFlight--
+	e.Unlock()
+	return nil
+}
+
+func (e *BlockingExecutorMock) InFlight() int {
+	e.Lock()
+	defer e.Unlock()
+	return e.inFlight
+}
+
+type FailingExecutorMock struct {
+	CounterMock
+}
+
+func (e *FailingExecutorMock) Execute(job entity.Job) error {
+	e.Inc("Execute")
+	return fmt.Errorf("connection refused")
+}
+
+type TickLagRecorderMock struct {
+	metrics.Nop
+	sync.Mutex
+	lags []time.Duration
+}
+
+func (m *TickLagRecorderMock) TickLag(lag time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.lags = append(m.lags, lag)
+}
+
+func (m *TickLagRecorderMock) Lags() []time.Duration {
+	m.Lock()
+	defer m.Unlock()
+	return append([]time.Duration(nil), m.lags...)
+}
+
+type TraceparentRecorderMock struct {
+	sync.Mutex
+	traceparents []string
+}
+
+func (e *TraceparentRecorderMock) Execute(job entity.Job) error {
+	e.Lock()
+	defer e.Unlock()
+	e.traceparents = append(e.traceparents, job.TraceParent)
+	return nil
+}
+
+func (e *TraceparentRecorderMock) Traceparents() []string {
+	e.Lock()
+	defer e.Unlock()
+	return append([]string(nil), e.traceparents...)
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context header propagating the trace of a request
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within its trace, as propagated by the W3C traceparent header
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid returns whether both the trace and span IDs are set
+func (c SpanContext) IsValid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+// Traceparent formats the context as a version 00 traceparent header value, blank when the
+// context isn't valid
+func (c SpanContext) Traceparent() string {
+	if !c.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(c.TraceID[:]), hex.EncodeToString(c.SpanID[:]), flags)
+}
+
+// ParseTraceparent parses a traceparent header value. Versions other than 00 are parsed by their
+// first four fields, as the specification requires.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var c SpanContext
+	fields := strings.Split(strings.TrimSpace(value), "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" || (fields[0] == "00" && len(fields) != 4) {
+		return c, fmt.Errorf("invalid traceparent: '%s'", value)
+	}
+	if !decodeHex(c.TraceID[:], fields[1]) || !decodeHex(c.SpanID[:], fields[2]) || len(fields[3]) != 2 {
+		return c, fmt.Errorf("invalid traceparent: '%s'", value)
+	}
+	flags, err := hex.DecodeString(fields[3])
+	if err != nil || !c.IsValid() {
+		return c, fmt.Errorf("invalid traceparent: '%s'", value)
+	}
+	c.Sampled = flags[0]&1 == 1
+	return c, nil
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func newTraceID() (id [16]byte) {
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id [8]byte) {
+	rand.Read(id[:])
+	return id
+}
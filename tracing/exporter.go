@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Exporter sends ended spans to a tracing backend
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// ExporterConfig holds the configuration of the exporters created by NewExporter
+type ExporterConfig struct {
+	// Writer receives the spans of the "stdout" exporter
+	Writer io.Writer
+	// OTLP configures the "otlp" exporter
+	OTLP OTLPConfig
+}
+
+// NewExporter creates an exporter of the given type. Currently acceptable values for
+// 'exporterType' are: "stdout", "otlp"
+func NewExporter(exporterType string, c ExporterConfig) (Exporter, error) {
+	switch exporterType {
+	case "stdout":
+		return NewWriterExporter(c.Writer), nil
+	case "otlp":
+		return NewOTLPExporter(c.OTLP), nil
+	}
+	return nil, fmt.Errorf("invalid trace exporter type: '%s'", exporterType)
+}
+
+// WriterExporter writes each span as a JSON line
+type WriterExporter struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterExporter returns a WriterExporter writing to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+// Export ...
+func (e *WriterExporter) Export(span SpanData) {
+	e.Lock()
+	defer e.Unlock()
+	e.encoder.Encode(span)
+}
+
+// InMemoryExporter keeps the spans exported, for tests
+type InMemoryExporter struct {
+	sync.Mutex
+	spans []SpanData
+}
+
+// Export ...
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported so far, in the order they ended
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.Lock()
+	defer e.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Find returns the first span exported with the given name
+func (e *InMemoryExporter) Find(name string) (SpanData, bool) {
+	for _, span := range e.Spans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return SpanData{}, false
+}
@@ -0,0 +1,261 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/marcoshack/schedula/logging"
+)
+
+var logger = logging.Component("tracing")
+
+const (
+	// DefaultOTLPEndpoint is the traces URL of an OpenTelemetry collector receiving OTLP/HTTP on the
+	// local host
+	DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+	// DefaultOTLPBatchSize is the maximum number of spans sent in a request
+	DefaultOTLPBatchSize = 512
+
+	// DefaultOTLPInterval is how long the spans wait to be sent when the batch isn't full
+	DefaultOTLPInterval = 5 * time.Second
+
+	// DefaultOTLPQueueSize is the number of spans waiting to be sent above which new ones are dropped
+	DefaultOTLPQueueSize = 2048
+
+	// DefaultOTLPTimeout is the timeout of the requests sending the spans
+	DefaultOTLPTimeout = 10 * time.Second
+
+	// otlpSpanKindInternal is the OTLP SPAN_KIND_INTERNAL, as spans don't record their kind
+	otlpSpanKindInternal = 1
+
+	// otlpStatusError is the OTLP STATUS_CODE_ERROR of the spans with an error
+	otlpStatusError = 2
+)
+
+// OTLPConfig holds OTLPExporter configuration parameters
+type OTLPConfig struct {
+	// Endpoint is the OTLP/HTTP traces URL. Defaults to DefaultOTLPEndpoint.
+	Endpoint string
+	// ServiceName is the 'service.name' resource attribute of the spans
+	ServiceName string
+	// BatchSize, Interval, QueueSize and Timeout default to the DefaultOTLP* values
+	BatchSize int
+	Interval  time.Duration
+	QueueSize int
+	Timeout   time.Duration
+}
+
+// OTLPExporter sends the spans in batches to an OpenTelemetry collector, or any backend receiving
+// OTLP/HTTP with the JSON encoding. Spans are queued and sent in the background, so ending a span
+// never waits on the backend: when the queue is full they're dropped, and a batch failing to be
+// sent is logged and dropped too.
+type OTLPExporter struct {
+	sync.RWMutex
+	config  OTLPConfig
+	client  *http.Client
+	spans   chan SpanData
+	done    chan struct{}
+	closed  bool
+	dropped int64
+}
+
+// NewOTLPExporter returns an OTLPExporter sending the spans until it's closed
+func NewOTLPExporter(c OTLPConfig) *OTLPExporter {
+	if c.Endpoint == "" {
+		c.Endpoint = DefaultOTLPEndpoint
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultOTLPBatchSize
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultOTLPInterval
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultOTLPQueueSize
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultOTLPTimeout
+	}
+	e := &OTLPExporter{
+		config: c,
+		client: &http.Client{Timeout: c.Timeout},
+		spans:  make(chan SpanData, c.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues the span to be sent, dropping it when the queue is full or the exporter closed
+func (e *OTLPExporter) Export(span SpanData) {
+	e.RLock()
+	defer e.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.spans <- span:
+	default:
+		atomic.AddInt64(&e.dropped, 1)
+	}
+}
+
+// Close sends the spans queued and stops the exporter
+func (e *OTLPExporter) Close() error {
+	e.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.spans)
+	}
+	e.Unlock()
+	<-e.done
+	return nil
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, e.config.BatchSize)
+	for {
+		select {
+		case span, ok := <-e.spans:
+			if !ok {
+				e.send(batch)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.config.BatchSize {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.send(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (e *OTLPExporter) send(batch []SpanData) {
+	if dropped := atomic.SwapInt64(&e.dropped, 0); dropped > 0 {
+		logger.Warn("spans dropped, export queue full", "count", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		logger.Error("error encoding spans", "count", len(batch), logging.KeyError, err)
+		return
+	}
+	res, err := e.client.Post(e.config.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Error("error exporting spans", "count", len(batch), logging.KeyError, err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		logger.Error("error exporting spans", "count", len(batch), logging.KeyStatus, res.StatusCode,
+			logging.KeyError, fmt.Errorf("unexpected response: %s", res.Status))
+	}
+}
+
+// request returns the OTLP ExportTraceServiceRequest of the batch, in its JSON encoding
+func (e *OTLPExporter) request(batch []SpanData) otlpTraces {
+	spans := make([]otlpSpan, len(batch))
+	for i, span := range batch {
+		spans[i] = otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.Error != "" {
+			spans[i].Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+	}
+	resource := otlpResource{Attributes: []otlpKeyValue{}}
+	if e.config.ServiceName != "" {
+		resource.Attributes = otlpAttributes(map[string]string{"service.name": e.config.ServiceName})
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "github.com/marcoshack/schedula/tracing"}, Spans: spans}},
+	}}}
+}
+
+// otlpAttributes returns the attributes as OTLP key values, sorted by key
+func otlpAttributes(attributes map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := make([]otlpKeyValue, len(keys))
+	for i, key := range keys {
+		res[i] = otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: attributes[key]}}
+	}
+	return res
+}
+
+// The OTLP trace messages, as encoded in JSON: IDs are hex strings and 64 bit integers are decimal
+// strings.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tracer starts spans and hands them to its exporter when they end. A nil Tracer starts nil
+// spans, which are no-ops, so tracing can be disabled by leaving it unset.
+type Tracer struct {
+	exporter Exporter
+	now      func() time.Time
+}
+
+// NewTracer returns a Tracer exporting its spans to the given exporter
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e, now: time.Now}
+}
+
+// Close closes the exporter when it's an io.Closer, like the OTLPExporter sending the spans still
+// queued
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	if closer, ok := t.exporter.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Span is an operation of a trace. Its methods are safe to call on a nil Span.
+type Span struct {
+	sync.Mutex
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+}
+
+// SpanData is the record of an ended span handed to the exporter
+type SpanData struct {
+	Name         string            `json:"name"`
+	Context      SpanContext       `json:"-"`
+	Parent       SpanContext       `json:"-"`
+	TraceID      string            `json:"traceId"`
+	SpanID       string            `json:"spanId"`
+	ParentSpanID string            `json:"parentSpanId,omitempty"`
+	Start        time.Time         `json:"startTime"`
+	End          time.Time         `json:"endTime"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// Start starts a span child of the span in the context, or a root span when there's none,
+// returning a context holding the new span
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := t.StartWithParent(SpanFromContext(ctx).Context(), name)
+	if span == nil {
+		return ctx, nil
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartWithParent starts a span child of the given span context, which may come from another
+// process, keeping its sampling decision. A root span, always sampled, is started when the parent
+// isn't valid.
+func (t *Tracer) StartWithParent(parent SpanContext, name string) *Span {
+	if t == nil {
+		return nil
+	}
+	c := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		c = SpanContext{TraceID: newTraceID(), SpanID: c.SpanID, Sampled: true}
+		parent = SpanContext{}
+	}
+	return &Span{tracer: t, data: SpanData{Name: name, Context: c, Parent: parent, Start: t.now()}}
+}
+
+// StartFromTraceparent starts a span child of the span in the traceparent value, or a root span
+// when it's blank or invalid
+func (t *Tracer) StartFromTraceparent(traceparent string, name string) *Span {
+	parent, _ := ParseTraceparent(traceparent)
+	return t.StartWithParent(parent, name)
+}
+
+// Context returns the span context, the zero value for a nil span
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// Traceparent returns the traceparent header value propagating the span
+func (s *Span) Traceparent() string {
+	return s.Context().Traceparent()
+}
+
+// SetAttribute sets a span attribute
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]string)
+	}
+	s.data.Attributes[key] = fmt.Sprintf("%v", value)
+}
+
+// RecordError marks the span as failed with the given error
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End ends the span and exports it when sampled. Only the first call has effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.ended {
+		s.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	data := s.data
+	s.Unlock()
+	if !data.Context.Sampled {
+		return
+	}
+
+	data.TraceID = hex.EncodeToString(data.Context.TraceID[:])
+	data.SpanID = hex.EncodeToString(data.Context.SpanID[:])
+	if data.Parent.IsValid() {
+		data.ParentSpanID = hex.EncodeToString(data.Parent.SpanID[:])
+	}
+	s.tracer.exporter.Export(data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context holding the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span held by the context, nil if there's none
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Middleware starts a span for each request, child of the one in its traceparent header
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := t.StartFromTraceparent(r.Header.Get(TraceparentHeader), "HTTP "+r.Method)
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(ContextWithSpan(r.Context(), span)))
+	})
+}
@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_ParseTraceparent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c, err := ParseTraceparent(value)
+	if err != nil {
+		t.Fatalf("unable to parse traceparent: %v", err)
+	}
+	if !c.Sampled || c.Traceparent() != value {
+		t.Fatalf("expected traceparent %s but got %s", value, c.Traceparent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(invalid); err == nil {
+			t.Fatalf("expected an error parsing traceparent '%s'", invalid)
+		}
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Fatalf("expected future versions to be parsed but got %v", err)
+	}
+}
+
+func Test_Tracer_ChildSpans(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute("job.id", "job-1")
+	child.End()
+	child.End()
+	root.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans exported once but got %d", len(spans))
+	}
+	if spans[0].TraceID != spans[1].TraceID || spans[0].ParentSpanID != spans[1].SpanID || spans[1].ParentSpanID != "" {
+		t.Fatalf("expected child span of the root span but got %+v", spans)
+	}
+	if spans[0].Attributes["job.id"] != "job-1" {
+		t.Fatalf("expected span attribute but got %v", spans[0].Attributes)
+	}
+}
+
+func Test_Tracer_KeepsSamplingDecision(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	span := NewTracer(exporter).StartFromTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "unsampled")
+	span.End()
+
+	if span.Context().Sampled || len(exporter.Spans()) != 0 {
+		t.Fatalf("expected span of an unsampled trace not to be exported")
+	}
+}
+
+func Test_Tracer_Nil(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "noop")
+	span.SetAttribute("key", "value")
+	span.End()
+	if span != nil || SpanFromContext(ctx) != nil || span.Traceparent() != "" {
+		t.Fatalf("expected nil tracer to start no spans")
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(NewWriterExporter(&buf))
+	var inner string
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "inner")
+		inner = span.Traceparent()
+		span.End()
+	}))
+
+	req := httptest.NewRequest("POST", "/jobs/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	decoder := json.NewDecoder(&buf)
+	var spans []SpanData
+	for decoder.More() {
+		var span SpanData
+		if err := decoder.Decode(&span); err != nil {
+			t.Fatalf("unable to decode exported span: %v", err)
+		}
+		spans = append(spans, span)
+	}
+	if len(spans) != 2 || spans[1].Name != "HTTP POST" || spans[1].ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("expected request span child of the incoming traceparent but got %+v", spans)
+	}
+	if spans[0].ParentSpanID != spans[1].SpanID || inner[3:35] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected inner span child of the request span but got %+v", spans)
+	}
+}
+
+func Test_OTLPExporter(t *testing.T) {
+	requests := make(chan otlpTraces, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON spans sent to /v1/traces but got %s to %s", r.Header.Get("Content-Type"), r.URL.Path)
+		}
+		var req otlpTraces
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("unable to decode OTLP request: %v", err)
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{Endpoint: server.URL + "/v1/traces", ServiceName: "schedula", BatchSize: 2, Interval: time.Hour})
+	tracer := NewTracer(exporter)
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute("job.id", "job-1")
+	child.RecordError(errors.New("callback failed"))
+	child.End()
+	root.End()
+
+	// the full batch is sent right away
+	req := <-requests
+	if len(req.ResourceSpans) != 1 || req.ResourceSpans[0].Resource.Attributes[0] != (otlpKeyValue{Key: "service.name", Value: otlpAnyValue{StringValue: "schedula"}}) {
+		t.Fatalf("expected spans of the schedula service but got %+v", req)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].Name != "child" || spans[0].ParentSpanID != spans[1].SpanID || spans[0].TraceID != spans[1].TraceID {
+		t.Fatalf("expected child span of the root span but got %+v", spans)
+	}
+	if spans[0].Status != (otlpStatus{Code: otlpStatusError, Message: "callback failed"}) || spans[1].Status.Code != 0 {
+		t.Fatalf("expected error status of the failed span only but got %+v and %+v", spans[0].Status, spans[1].Status)
+	}
+	if len(spans[0].Attributes) != 1 || spans[0].Attributes[0].Key != "job.id" || spans[0].StartTimeUnixNano == "" {
+		t.Fatalf("expected span attributes and times but got %+v", spans[0])
+	}
+
+	// closing sends the spans queued
+	_, last := tracer.Start(context.Background(), "last")
+	last.End()
+	tracer.Close()
+	if req := <-requests; req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name != "last" {
+		t.Fatalf("expected queued span sent on close but got %+v", req)
+	}
+	exporter.Export(SpanData{Name: "closed"})
+}